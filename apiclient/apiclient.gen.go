@@ -0,0 +1,792 @@
+// Code generated by apiclient/gen from openapi.json; DO NOT EDIT.
+
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// APIVersion the version of the OpenAPI document the client is generated from
+const APIVersion = "2.0.0"
+
+// make sure the imports are used even if no operation needs them
+var (
+	_ = fmt.Sprint
+	_ http.Response
+)
+
+// AddProgramRequest the AddProgramRequest object
+type AddProgramRequest struct {
+	// the settings of the [program:x] section, command is required
+	Config map[string]interface{} `json:"config"`
+	// the program name
+	Name string `json:"name"`
+	// save the program to programs_dir so it is kept across reloads
+	Persist bool `json:"persist,omitempty"`
+}
+
+// EffectiveProgram the EffectiveProgram object
+type EffectiveProgram struct {
+	Group string `json:"group"`
+	// the process name
+	Name string `json:"name"`
+	// the [program:x] or [eventlistener:x] section creating the process
+	Section string           `json:"section"`
+	Values  []EffectiveValue `json:"values"`
+}
+
+// EffectiveValue the EffectiveValue object
+type EffectiveValue struct {
+	// the file where the key is set
+	File string `json:"file,omitempty"`
+	Key  string `json:"key"`
+	// the line where the key is set
+	Line int `json:"line,omitempty"`
+	// the value in the configuration if it is different from value
+	Raw string `json:"raw,omitempty"`
+	// the section where the key is set
+	Section string `json:"section,omitempty"`
+	// file: set in the program section, inherited: from [program-default], expanded: set by the numprocs expansion, runtime: set by adding or scaling the program at runtime, default: the built-in default
+	Source string `json:"source"`
+	// the value after the %(var)s expressions are evaluated
+	Value string `json:"value"`
+}
+
+// Error the Error object
+type Error struct {
+	// the fault code, e.g. 10 for BAD_NAME
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Group the Group object
+type Group struct {
+	Name      string        `json:"name"`
+	Processes []ProcessInfo `json:"processes"`
+}
+
+// LogMessage the LogMessage object
+type LogMessage struct {
+	Data string `json:"data"`
+	// the offset in current log file after the data
+	Offset int64 `json:"offset"`
+	// the log file is rotated or cleared, the offset restarts from 0
+	Rotated bool `json:"rotated,omitempty"`
+}
+
+// LogTail the LogTail object
+type LogTail struct {
+	Data string `json:"data"`
+	// the offset for next reading
+	Offset   int64 `json:"offset"`
+	Overflow bool  `json:"overflow"`
+}
+
+// ProcessInfo the ProcessInfo object
+type ProcessInfo struct {
+	// the delay in milliseconds before the next start attempt, 0 if no attempt is scheduled
+	BackoffDelayMs int    `json:"backoff_delay_ms"`
+	Description    string `json:"description"`
+	Exitstatus     int    `json:"exitstatus"`
+	Group          string `json:"group"`
+	Health         string `json:"health"`
+	Logfile        string `json:"logfile"`
+	Name           string `json:"name"`
+	// the unix time of the next start attempt, 0 if no attempt is scheduled
+	NextAttempt   int    `json:"next_attempt"`
+	Now           int    `json:"now"`
+	Pid           int    `json:"pid"`
+	Spawnerr      string `json:"spawnerr"`
+	Start         int    `json:"start"`
+	State         int    `json:"state"`
+	Statename     string `json:"statename"`
+	StderrLogfile string `json:"stderr_logfile"`
+	StdoutLogfile string `json:"stdout_logfile"`
+	Stop          int    `json:"stop"`
+}
+
+// ProgramConfig the ProgramConfig object
+type ProgramConfig struct {
+	Autostart      bool   `json:"autostart"`
+	Command        string `json:"command"`
+	Directory      string `json:"directory"`
+	Group          string `json:"group"`
+	GroupPrio      int    `json:"group_prio"`
+	Inuse          bool   `json:"inuse"`
+	Killasgroup    bool   `json:"killasgroup"`
+	Name           string `json:"name"`
+	ProcessPrio    int    `json:"process_prio"`
+	RedirectStderr bool   `json:"redirect_stderr"`
+	Startretries   int    `json:"startretries"`
+	Startsecs      int    `json:"startsecs"`
+	StderrLogfile  string `json:"stderr_logfile"`
+	StdoutLogfile  string `json:"stdout_logfile"`
+	Stopasgroup    bool   `json:"stopasgroup"`
+	Stopsignal     string `json:"stopsignal"`
+	Stopwaitsecs   int    `json:"stopwaitsecs"`
+}
+
+// ProgramResult the ProgramResult object
+type ProgramResult struct {
+	// the process names of the instances
+	Processes []string `json:"processes"`
+	Program   string   `json:"program"`
+}
+
+// ReloadResult the ReloadResult object
+type ReloadResult struct {
+	Added []string `json:"added"`
+	// the added programs
+	AddedPrograms []string `json:"added_programs"`
+	Changed       []string `json:"changed"`
+	// the programs whose settings are changed, they are restarted if running
+	ChangedPrograms []string `json:"changed_programs"`
+	Removed         []string `json:"removed"`
+	// the removed programs, they are stopped
+	RemovedPrograms []string `json:"removed_programs"`
+}
+
+// RollingRestartRequest the RollingRestartRequest object
+type RollingRestartRequest struct {
+	// the number of instances restarted at the same time, default 1
+	Batch int `json:"batch,omitempty"`
+	// the seconds to wait for every batch, default 60
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// ScaleRequest the ScaleRequest object
+type ScaleRequest struct {
+	// the new number of the instances
+	Numprocs int `json:"numprocs"`
+	// keep the numprocs across reloads and save it to numprocs_file
+	Persist bool `json:"persist,omitempty"`
+}
+
+// ScaleResult the ScaleResult object
+type ScaleResult struct {
+	// the process names of the added instances
+	Added    []string `json:"added"`
+	Numprocs int      `json:"numprocs"`
+	Program  string   `json:"program"`
+	// the process names of the removed instances
+	Removed []string `json:"removed"`
+}
+
+// SignalRequest the SignalRequest object
+type SignalRequest struct {
+	// send the signal to the process group of the process
+	AsGroup bool `json:"as_group,omitempty"`
+	// the signal name like HUP or SIGHUP, or the signal number
+	Signal string `json:"signal"`
+}
+
+// StdinRequest the StdinRequest object
+type StdinRequest struct {
+	Chars string `json:"chars"`
+}
+
+// Success the Success object
+type Success struct {
+	Success bool `json:"success"`
+}
+
+// SupervisorInfo the SupervisorInfo object
+type SupervisorInfo struct {
+	APIVersion     string `json:"api_version"`
+	Identification string `json:"identification"`
+	Pid            int    `json:"pid"`
+	State          string `json:"state"`
+	Version        string `json:"version"`
+}
+
+// TaskResult the TaskResult object
+type TaskResult struct {
+	Description string `json:"description"`
+	Group       string `json:"group"`
+	Name        string `json:"name"`
+	// the fault code, 80 means success
+	Status int `json:"status"`
+}
+
+// GetEffectiveConfigParams the query parameters of GetEffectiveConfig
+type GetEffectiveConfigParams struct {
+	// the program or process names to select, all the programs if not provided
+	Program *[]string
+	// the response format
+	Format *string
+}
+
+// GetEffectiveConfig get the effective configuration of the programs and where every value comes from
+func (c *Client) GetEffectiveConfig(ctx context.Context, params *GetEffectiveConfigParams) ([]EffectiveProgram, error) {
+	path := "/config"
+	query := url.Values{}
+	if params != nil {
+		if params.Program != nil {
+			for _, v := range *params.Program {
+				query.Add("program", fmt.Sprint(v))
+			}
+		}
+		if params.Format != nil {
+			query.Set("format", fmt.Sprint(*params.Format))
+		}
+	}
+	resp, err := c.do(ctx, "GET", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []EffectiveProgram
+	if err = decodeJSONResponse(resp, &result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// ListGroups list all the groups and their processes
+func (c *Client) ListGroups(ctx context.Context) ([]Group, error) {
+	path := "/groups"
+	query := url.Values{}
+	resp, err := c.do(ctx, "GET", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []Group
+	if err = decodeJSONResponse(resp, &result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// GetGroup get a group and its processes
+func (c *Client) GetGroup(ctx context.Context, group string) (*Group, error) {
+	path := "/groups/" + url.PathEscape(group)
+	query := url.Values{}
+	resp, err := c.do(ctx, "GET", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := new(Group)
+	if err = decodeJSONResponse(resp, result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// RestartGroupParams the query parameters of RestartGroup
+type RestartGroupParams struct {
+	// the seconds to wait for the whole group, default 60
+	Timeout *int
+}
+
+// RestartGroup restart all the processes in a group
+//
+// The processes are stopped in the reverse depends_on/priority order and started again in the depends_on/priority order. The processes not handled before the timeout are reported with description SKIPPED, the process being waited with TIMEOUT.
+//
+// The result is also returned with a *StatusError if the http status is one of 409, 500
+func (c *Client) RestartGroup(ctx context.Context, group string, params *RestartGroupParams) ([]TaskResult, error) {
+	path := "/groups/" + url.PathEscape(group) + "/restart"
+	query := url.Values{}
+	if params != nil {
+		if params.Timeout != nil {
+			query.Set("timeout", fmt.Sprint(*params.Timeout))
+		}
+	}
+	resp, err := c.do(ctx, "POST", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []TaskResult
+	if err = decodeJSONResponse(resp, &result, []int{409, 500}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// StartGroupParams the query parameters of StartGroup
+type StartGroupParams struct {
+	// the seconds to wait for the whole group, default 60
+	Timeout *int
+}
+
+// StartGroup start all the processes in a group
+//
+// The processes are started one by one in the depends_on/priority order, every process must be Running before the next one is started. The processes not handled before the timeout are reported with description SKIPPED, the process being waited with TIMEOUT.
+//
+// The result is also returned with a *StatusError if the http status is one of 409, 500
+func (c *Client) StartGroup(ctx context.Context, group string, params *StartGroupParams) ([]TaskResult, error) {
+	path := "/groups/" + url.PathEscape(group) + "/start"
+	query := url.Values{}
+	if params != nil {
+		if params.Timeout != nil {
+			query.Set("timeout", fmt.Sprint(*params.Timeout))
+		}
+	}
+	resp, err := c.do(ctx, "POST", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []TaskResult
+	if err = decodeJSONResponse(resp, &result, []int{409, 500}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// StopGroupParams the query parameters of StopGroup
+type StopGroupParams struct {
+	// the seconds to wait for the whole group, default 60
+	Timeout *int
+}
+
+// StopGroup stop all the processes in a group
+//
+// The processes are stopped one by one in the reverse depends_on/priority order, every process must be stopped before the previous one is stopped. The processes not handled before the timeout are reported with description SKIPPED, the process being waited with TIMEOUT.
+//
+// The result is also returned with a *StatusError if the http status is one of 409, 500
+func (c *Client) StopGroup(ctx context.Context, group string, params *StopGroupParams) ([]TaskResult, error) {
+	path := "/groups/" + url.PathEscape(group) + "/stop"
+	query := url.Values{}
+	if params != nil {
+		if params.Timeout != nil {
+			query.Set("timeout", fmt.Sprint(*params.Timeout))
+		}
+	}
+	resp, err := c.do(ctx, "POST", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []TaskResult
+	if err = decodeJSONResponse(resp, &result, []int{409, 500}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// GetOpenAPIDocument get this OpenAPI document
+func (c *Client) GetOpenAPIDocument(ctx context.Context) (map[string]interface{}, error) {
+	path := "/openapi.json"
+	query := url.Values{}
+	resp, err := c.do(ctx, "GET", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err = decodeJSONResponse(resp, &result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// ListProcesses list the information of all the processes
+func (c *Client) ListProcesses(ctx context.Context) ([]ProcessInfo, error) {
+	path := "/processes"
+	query := url.Values{}
+	resp, err := c.do(ctx, "GET", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []ProcessInfo
+	if err = decodeJSONResponse(resp, &result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// GetProcess get the information of a process
+func (c *Client) GetProcess(ctx context.Context, name string) (*ProcessInfo, error) {
+	path := "/processes/" + url.PathEscape(name)
+	query := url.Values{}
+	resp, err := c.do(ctx, "GET", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := new(ProcessInfo)
+	if err = decodeJSONResponse(resp, result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// ReadProcessLogParams the query parameters of ReadProcessLog
+type ReadProcessLogParams struct {
+	// the offset to read from, negative offset is from the end of log if length is 0
+	Offset *int64
+	// the max bytes to read, 0 means to the end of log
+	Length *int64
+}
+
+// ReadProcessLog read the stdout or stderr log of a process
+func (c *Client) ReadProcessLog(ctx context.Context, name string, stream string, params *ReadProcessLogParams) (string, error) {
+	path := "/processes/" + url.PathEscape(name) + "/logs/" + url.PathEscape(stream)
+	query := url.Values{}
+	if params != nil {
+		if params.Offset != nil {
+			query.Set("offset", fmt.Sprint(*params.Offset))
+		}
+		if params.Length != nil {
+			query.Set("length", fmt.Sprint(*params.Length))
+		}
+	}
+	resp, err := c.do(ctx, "GET", path, query, nil)
+	if err != nil {
+		return "", err
+	}
+	return decodeTextResponse(resp)
+}
+
+// StreamProcessLogParams the query parameters of StreamProcessLog
+type StreamProcessLogParams struct {
+	// the offset to resume from, negative offset is from the end of log. The last 1600 bytes are streamed if not provided
+	Offset *int64
+}
+
+// StreamProcessLog stream the log of a process with server-sent events
+//
+// The id of each event is the offset in the log file, the data is a LogMessage in json.
+//
+// The caller must close the body of the returned response
+func (c *Client) StreamProcessLog(ctx context.Context, name string, stream string, params *StreamProcessLogParams) (*http.Response, error) {
+	path := "/processes/" + url.PathEscape(name) + "/logs/" + url.PathEscape(stream) + "/stream"
+	query := url.Values{}
+	if params != nil {
+		if params.Offset != nil {
+			query.Set("offset", fmt.Sprint(*params.Offset))
+		}
+	}
+	resp, err := c.do(ctx, "GET", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkStreamResponse(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// TailProcessLogParams the query parameters of TailProcessLog
+type TailProcessLogParams struct {
+	// the offset to read from, the last length bytes are read if not provided
+	Offset *int64
+	// the max bytes to read
+	Length *int64
+}
+
+// TailProcessLog tail the stdout or stderr log of a process
+func (c *Client) TailProcessLog(ctx context.Context, name string, stream string, params *TailProcessLogParams) (*LogTail, error) {
+	path := "/processes/" + url.PathEscape(name) + "/logs/" + url.PathEscape(stream) + "/tail"
+	query := url.Values{}
+	if params != nil {
+		if params.Offset != nil {
+			query.Set("offset", fmt.Sprint(*params.Offset))
+		}
+		if params.Length != nil {
+			query.Set("length", fmt.Sprint(*params.Length))
+		}
+	}
+	resp, err := c.do(ctx, "GET", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := new(LogTail)
+	if err = decodeJSONResponse(resp, result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// ResetRestartBreaker clear the restart history of the processes so they are restarted automatically again after exceeding restart_limit
+func (c *Client) ResetRestartBreaker(ctx context.Context, name string) ([]TaskResult, error) {
+	path := "/processes/" + url.PathEscape(name) + "/reset-breaker"
+	query := url.Values{}
+	resp, err := c.do(ctx, "POST", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []TaskResult
+	if err = decodeJSONResponse(resp, &result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// RestartProcess restart the processes
+//
+// The result is also returned with a *StatusError if the http status is one of 409, 500
+func (c *Client) RestartProcess(ctx context.Context, name string) ([]TaskResult, error) {
+	path := "/processes/" + url.PathEscape(name) + "/restart"
+	query := url.Values{}
+	resp, err := c.do(ctx, "POST", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []TaskResult
+	if err = decodeJSONResponse(resp, &result, []int{409, 500}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// SignalProcess send a signal to the processes
+//
+// The result is also returned with a *StatusError if the http status is one of 409, 500
+func (c *Client) SignalProcess(ctx context.Context, name string, body SignalRequest) ([]TaskResult, error) {
+	path := "/processes/" + url.PathEscape(name) + "/signal"
+	query := url.Values{}
+	resp, err := c.do(ctx, "POST", path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	var result []TaskResult
+	if err = decodeJSONResponse(resp, &result, []int{409, 500}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// StartProcess start the processes
+//
+// The result is also returned with a *StatusError if the http status is one of 409, 500
+func (c *Client) StartProcess(ctx context.Context, name string) ([]TaskResult, error) {
+	path := "/processes/" + url.PathEscape(name) + "/start"
+	query := url.Values{}
+	resp, err := c.do(ctx, "POST", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []TaskResult
+	if err = decodeJSONResponse(resp, &result, []int{409, 500}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// SendProcessStdin write chars to the stdin of the processes
+//
+// The result is also returned with a *StatusError if the http status is one of 409, 500
+func (c *Client) SendProcessStdin(ctx context.Context, name string, body StdinRequest) ([]TaskResult, error) {
+	path := "/processes/" + url.PathEscape(name) + "/stdin"
+	query := url.Values{}
+	resp, err := c.do(ctx, "POST", path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	var result []TaskResult
+	if err = decodeJSONResponse(resp, &result, []int{409, 500}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// StopProcess stop the processes
+//
+// The result is also returned with a *StatusError if the http status is one of 409, 500
+func (c *Client) StopProcess(ctx context.Context, name string) ([]TaskResult, error) {
+	path := "/processes/" + url.PathEscape(name) + "/stop"
+	query := url.Values{}
+	resp, err := c.do(ctx, "POST", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []TaskResult
+	if err = decodeJSONResponse(resp, &result, []int{409, 500}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// ListPrograms list the configuration of all the programs
+func (c *Client) ListPrograms(ctx context.Context) ([]ProgramConfig, error) {
+	path := "/programs"
+	query := url.Values{}
+	resp, err := c.do(ctx, "GET", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []ProgramConfig
+	if err = decodeJSONResponse(resp, &result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// AddProgram add a program defined by the settings of a [program:x] section without editing the configuration files
+//
+// The settings in [program-default] section are applied. Unless persist is true, the program is removed when the configuration is reloaded. A persisted program is saved to programs_dir in [supervisord] section and a PROCESS_GROUP_ADDED event is emitted if the group of the program is new.
+func (c *Client) AddProgram(ctx context.Context, body AddProgramRequest) (*ProgramResult, error) {
+	path := "/programs"
+	query := url.Values{}
+	resp, err := c.do(ctx, "POST", path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	result := new(ProgramResult)
+	if err = decodeJSONResponse(resp, result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// RemoveProgram stop the processes of a program and remove it
+//
+// The program saved to programs_dir is deleted, a program defined in the configuration files is added again when the configuration is reloaded. A PROCESS_GROUP_REMOVED event is emitted if the group has no processes left.
+func (c *Client) RemoveProgram(ctx context.Context, name string) (*ProgramResult, error) {
+	path := "/programs/" + url.PathEscape(name)
+	query := url.Values{}
+	resp, err := c.do(ctx, "DELETE", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := new(ProgramResult)
+	if err = decodeJSONResponse(resp, result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// GetProgram get the configuration of a program
+func (c *Client) GetProgram(ctx context.Context, name string) (*ProgramConfig, error) {
+	path := "/programs/" + url.PathEscape(name)
+	query := url.Values{}
+	resp, err := c.do(ctx, "GET", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := new(ProgramConfig)
+	if err = decodeJSONResponse(resp, result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// GetProgramConfFile get the content of the conf_file of a program
+func (c *Client) GetProgramConfFile(ctx context.Context, name string) (string, error) {
+	path := "/programs/" + url.PathEscape(name) + "/conf"
+	query := url.Values{}
+	resp, err := c.do(ctx, "GET", path, query, nil)
+	if err != nil {
+		return "", err
+	}
+	return decodeTextResponse(resp)
+}
+
+// RollingRestartProgram restart the instances of a program one batch at a time
+//
+// Every instance of a batch must be Running, and healthy if it has a health check, before the next batch is restarted. After an instance fails, the rest are not restarted and are reported with description SKIPPED. It returns 409 if the program is being restarted already.
+func (c *Client) RollingRestartProgram(ctx context.Context, name string, body RollingRestartRequest) ([]TaskResult, error) {
+	path := "/programs/" + url.PathEscape(name) + "/rolling-restart"
+	query := url.Values{}
+	resp, err := c.do(ctx, "POST", path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	var result []TaskResult
+	if err = decodeJSONResponse(resp, &result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// ScaleProgram change the number of the instances of a program, the added instances are started if autostart is true and the instances with the highest process_num are stopped
+func (c *Client) ScaleProgram(ctx context.Context, name string, body ScaleRequest) (*ScaleResult, error) {
+	path := "/programs/" + url.PathEscape(name) + "/scale"
+	query := url.Values{}
+	resp, err := c.do(ctx, "POST", path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	result := new(ScaleResult)
+	if err = decodeJSONResponse(resp, result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// GetSupervisor get the version, state and pid of supervisord
+func (c *Client) GetSupervisor(ctx context.Context) (*SupervisorInfo, error) {
+	path := "/supervisor"
+	query := url.Values{}
+	resp, err := c.do(ctx, "GET", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := new(SupervisorInfo)
+	if err = decodeJSONResponse(resp, result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// StreamEventsParams the query parameters of StreamEvents
+type StreamEventsParams struct {
+	// comma separated event types, abstract types like PROCESS_STATE are accepted
+	Type *string
+	// comma separated program names ("name" or "group:name")
+	Program *string
+	// comma separated group names
+	Group  *string
+	Format *string
+}
+
+// StreamEvents stream the supervisor events
+//
+// Each event is a json object with the serial, type and the fields of the event body. Log events are only emitted for programs with stdout_events_enabled or stderr_events_enabled.
+//
+// The caller must close the body of the returned response
+func (c *Client) StreamEvents(ctx context.Context, params *StreamEventsParams) (*http.Response, error) {
+	path := "/supervisor/events"
+	query := url.Values{}
+	if params != nil {
+		if params.Type != nil {
+			query.Set("type", fmt.Sprint(*params.Type))
+		}
+		if params.Program != nil {
+			query.Set("program", fmt.Sprint(*params.Program))
+		}
+		if params.Group != nil {
+			query.Set("group", fmt.Sprint(*params.Group))
+		}
+		if params.Format != nil {
+			query.Set("format", fmt.Sprint(*params.Format))
+		}
+	}
+	resp, err := c.do(ctx, "GET", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkStreamResponse(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ReloadSupervisor reload the configuration and apply the changes, only the running programs whose settings are changed are restarted
+func (c *Client) ReloadSupervisor(ctx context.Context) (*ReloadResult, error) {
+	path := "/supervisor/reload"
+	query := url.Values{}
+	resp, err := c.do(ctx, "POST", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := new(ReloadResult)
+	if err = decodeJSONResponse(resp, result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
+
+// ShutdownSupervisor stop all the processes and shut down supervisord
+func (c *Client) ShutdownSupervisor(ctx context.Context) (*Success, error) {
+	path := "/supervisor/shutdown"
+	query := url.Values{}
+	resp, err := c.do(ctx, "POST", path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := new(Success)
+	if err = decodeJSONResponse(resp, result, []int{}); err != nil && !isStatusError(err) {
+		return nil, err
+	}
+	return result, err
+}
@@ -0,0 +1,158 @@
+// Package apiclient is the go client of the supervisord /api/v2 restful interface.
+//
+// The operations and models in apiclient.gen.go are generated from the OpenAPI
+// document served by supervisord at /api/v2/openapi.json
+package apiclient
+
+//go:generate go run ./gen ../openapi.json apiclient.gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client the client of the supervisord /api/v2 restful interface
+type Client struct {
+	// the url of supervisord like http://127.0.0.1:9001
+	BaseURL string
+	// the user and password for http basic authentication
+	User     string
+	Password string
+	// the http client to send the requests, http.DefaultClient is used if it is nil
+	HTTPClient *http.Client
+}
+
+// ResponseError the error returned by supervisord for the failed request
+type ResponseError struct {
+	StatusCode int
+	// the fault code like 10 for BAD_NAME
+	Code    int
+	Message string
+}
+
+// Error implements the error interface
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("http status %d: %s (code %d)", e.StatusCode, e.Message, e.Code)
+}
+
+// StatusError the error returned with the result if the request fails for some or all of
+// the targets, for example starting an already started process
+type StatusError struct {
+	StatusCode int
+}
+
+// Error implements the error interface
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("http status %d: %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func isStatusError(err error) bool {
+	_, ok := err.(*StatusError)
+	return ok
+}
+
+// NewClient creates a Client object to access supervisord at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+// String returns the pointer of the string value, it is used to set the optional parameters
+func String(v string) *string {
+	return &v
+}
+
+// Int64 returns the pointer of the int64 value, it is used to set the optional parameters
+func Int64(v int64) *int64 {
+	return &v
+}
+
+// send the request to supervisord, the body is encoded in json if it is not nil
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body interface{}) (*http.Response, error) {
+	u := strings.TrimSuffix(c.BaseURL, "/") + "/api/v2" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.User != "" || c.Password != "" {
+		req.SetBasicAuth(c.User, c.Password)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return httpClient.Do(req)
+}
+
+// decode the error body of the failed response
+func decodeError(resp *http.Response) error {
+	respErr := &ResponseError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	var body Error
+	if err := json.NewDecoder(resp.Body).Decode(&body); err == nil {
+		respErr.Code = body.Code
+		respErr.Message = body.Message
+	}
+	return respErr
+}
+
+// decode the json response to result. The response with one of resultStatusCodes is also
+// decoded to result and a StatusError is returned
+func decodeJSONResponse(resp *http.Response, result interface{}, resultStatusCodes []int) error {
+	defer resp.Body.Close()
+
+	var err error
+	if resp.StatusCode == http.StatusMultiStatus {
+		// some targets succeed and others fail
+		err = &StatusError{StatusCode: resp.StatusCode}
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err = &StatusError{StatusCode: resp.StatusCode}
+		isResult := false
+		for _, code := range resultStatusCodes {
+			isResult = isResult || code == resp.StatusCode
+		}
+		if !isResult {
+			return decodeError(resp)
+		}
+	}
+	if decodeErr := json.NewDecoder(resp.Body).Decode(result); decodeErr != nil {
+		return decodeErr
+	}
+	return err
+}
+
+func decodeTextResponse(resp *http.Response) (string, error) {
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", decodeError(resp)
+	}
+	b, err := io.ReadAll(resp.Body)
+	return string(b), err
+}
+
+func checkStreamResponse(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return decodeError(resp)
+	}
+	return nil
+}
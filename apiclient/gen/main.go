@@ -0,0 +1,389 @@
+// The gen command generates the go client of the supervisord /api/v2 restful interface
+// from its OpenAPI document.
+//
+// Usage:
+//
+//	go run ./gen <openapi.json> <output.go>
+//
+// Only the subset of OpenAPI used by the supervisord document is supported: object
+// schemas with primitive, array and $ref properties, path and query parameters of
+// primitive types, json request bodies and json, text or streaming responses
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Schema the OpenAPI schema object
+type Schema struct {
+	Ref         string             `json:"$ref"`
+	Type        string             `json:"type"`
+	Format      string             `json:"format"`
+	Description string             `json:"description"`
+	Properties  map[string]*Schema `json:"properties"`
+	Required    []string           `json:"required"`
+	Items       *Schema            `json:"items"`
+}
+
+// MediaType the OpenAPI media type object
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Response the OpenAPI response object
+type Response struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// Parameter the OpenAPI parameter object
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody the OpenAPI request body object
+type RequestBody struct {
+	Content map[string]*MediaType `json:"content"`
+}
+
+// Operation the OpenAPI operation object
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	Parameters  []*Parameter         `json:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Document the OpenAPI document
+type Document struct {
+	Info struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Responses map[string]*Response `json:"responses"`
+		Schemas   map[string]*Schema   `json:"schemas"`
+	} `json:"components"`
+}
+
+// the words written in upper case in go names
+var initialisms = map[string]bool{"api": true, "id": true, "url": true, "http": true, "json": true}
+
+// convert the OpenAPI name like "api_version" or "getSupervisor" to go name like "APIVersion" or "GetSupervisor"
+func goName(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+		} else {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// get the go type of the schema
+func goType(schema *Schema) string {
+	if schema.Ref != "" {
+		return refName(schema.Ref)
+	}
+	switch schema.Type {
+	case "string":
+		return "string"
+	case "integer":
+		if schema.Format == "int64" {
+			return "int64"
+		}
+		return "int"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + goType(schema.Items)
+	}
+	return "map[string]interface{}"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeComment(b *bytes.Buffer, indent string, name string, text string) {
+	if text == "" {
+		return
+	}
+	lines := strings.Split(text, "\n")
+	if name != "" {
+		lines[0] = name + " " + strings.ToLower(lines[0][:1]) + lines[0][1:]
+	}
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
+
+func generateSchemas(b *bytes.Buffer, doc *Document) {
+	for _, name := range sortedKeys(doc.Components.Schemas) {
+		schema := doc.Components.Schemas[name]
+		if schema.Description != "" {
+			writeComment(b, "", name, schema.Description)
+		} else {
+			fmt.Fprintf(b, "// %s the %s object\n", name, name)
+		}
+		fmt.Fprintf(b, "type %s struct {\n", name)
+		required := make(map[string]bool)
+		for _, r := range schema.Required {
+			required[r] = true
+		}
+		for _, propName := range sortedKeys(schema.Properties) {
+			prop := schema.Properties[propName]
+			writeComment(b, "\t", "", prop.Description)
+			omitEmpty := ""
+			if !required[propName] {
+				omitEmpty = ",omitempty"
+			}
+			fmt.Fprintf(b, "\t%s %s `json:\"%s%s\"`\n", goName(propName), goType(prop), propName, omitEmpty)
+		}
+		fmt.Fprintf(b, "}\n\n")
+	}
+}
+
+// resolve the response reference
+func resolveResponse(doc *Document, resp *Response) *Response {
+	if resp.Ref != "" {
+		return doc.Components.Responses[refName(resp.Ref)]
+	}
+	return resp
+}
+
+type operationInfo struct {
+	path      string
+	method    string
+	op        *Operation
+	name      string
+	pathArgs  []*Parameter
+	query     []*Parameter
+	bodyType  string
+	kind      string // json, text or stream
+	result    *Schema
+	resultFor []string
+}
+
+// analyze the operation to get the go method signature
+func analyzeOperation(doc *Document, path string, method string, op *Operation) *operationInfo {
+	info := &operationInfo{path: path, method: strings.ToUpper(method), op: op, name: goName(op.OperationID)}
+	for _, param := range op.Parameters {
+		if param.In == "path" {
+			info.pathArgs = append(info.pathArgs, param)
+		} else if param.In == "query" {
+			info.query = append(info.query, param)
+		}
+	}
+	if op.RequestBody != nil {
+		if mt, ok := op.RequestBody.Content["application/json"]; ok {
+			info.bodyType = goType(mt.Schema)
+		}
+	}
+	for _, code := range sortedKeys(op.Responses) {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		resp := resolveResponse(doc, op.Responses[code])
+		if mt, ok := resp.Content["application/json"]; ok {
+			info.kind = "json"
+			info.result = mt.Schema
+		} else if _, ok := resp.Content["text/plain"]; ok {
+			info.kind = "text"
+		} else {
+			info.kind = "stream"
+		}
+		break
+	}
+	if info.kind == "json" {
+		// the error responses with same schema as the successful response
+		resultType := goType(info.result)
+		for _, code := range sortedKeys(op.Responses) {
+			if strings.HasPrefix(code, "2") {
+				continue
+			}
+			resp := resolveResponse(doc, op.Responses[code])
+			if mt, ok := resp.Content["application/json"]; ok && goType(mt.Schema) == resultType {
+				info.resultFor = append(info.resultFor, code)
+			}
+		}
+	}
+	return info
+}
+
+func generateOperation(b *bytes.Buffer, info *operationInfo) {
+	args := []string{"ctx context.Context"}
+	for _, param := range info.pathArgs {
+		args = append(args, fmt.Sprintf("%s %s", param.Name, goType(param.Schema)))
+	}
+	if info.bodyType != "" {
+		args = append(args, fmt.Sprintf("body %s", info.bodyType))
+	}
+	if len(info.query) > 0 {
+		fmt.Fprintf(b, "// %sParams the query parameters of %s\n", info.name, info.name)
+		fmt.Fprintf(b, "type %sParams struct {\n", info.name)
+		for _, param := range info.query {
+			writeComment(b, "\t", "", param.Description)
+			fmt.Fprintf(b, "\t%s *%s\n", goName(param.Name), goType(param.Schema))
+		}
+		fmt.Fprintf(b, "}\n\n")
+		args = append(args, fmt.Sprintf("params *%sParams", info.name))
+	}
+
+	var resultType, zero string
+	switch info.kind {
+	case "json":
+		resultType = goType(info.result)
+		if info.result.Ref != "" {
+			resultType = "*" + resultType
+		}
+		zero = "nil"
+	case "text":
+		resultType, zero = "string", `""`
+	default:
+		resultType, zero = "*http.Response", "nil"
+	}
+
+	writeComment(b, "", info.name, info.op.Summary)
+	if info.op.Description != "" {
+		fmt.Fprintf(b, "//\n")
+		writeComment(b, "", "", info.op.Description)
+	}
+	if info.kind == "stream" {
+		fmt.Fprintf(b, "//\n// The caller must close the body of the returned response\n")
+	}
+	if len(info.resultFor) > 0 {
+		fmt.Fprintf(b, "//\n// The result is also returned with a *StatusError if the http status is one of %s\n", strings.Join(info.resultFor, ", "))
+	}
+	fmt.Fprintf(b, "func (c *Client) %s(%s) (%s, error) {\n", info.name, strings.Join(args, ", "), resultType)
+
+	path := info.path
+	for _, param := range info.pathArgs {
+		path = strings.Replace(path, "{"+param.Name+"}", `" + url.PathEscape(`+param.Name+`) + "`, 1)
+	}
+	path = strings.TrimSuffix(`"`+path+`"`, ` + ""`)
+	fmt.Fprintf(b, "\tpath := %s\n", path)
+
+	fmt.Fprintf(b, "\tquery := url.Values{}\n")
+	if len(info.query) > 0 {
+		fmt.Fprintf(b, "\tif params != nil {\n")
+		for _, param := range info.query {
+			field := goName(param.Name)
+			fmt.Fprintf(b, "\t\tif params.%s != nil {\n", field)
+			if param.Schema != nil && param.Schema.Type == "array" {
+				// the array is sent as a repeated query parameter
+				fmt.Fprintf(b, "\t\t\tfor _, v := range *params.%s {\n", field)
+				fmt.Fprintf(b, "\t\t\t\tquery.Add(%q, fmt.Sprint(v))\n", param.Name)
+				fmt.Fprintf(b, "\t\t\t}\n")
+			} else {
+				fmt.Fprintf(b, "\t\t\tquery.Set(%q, fmt.Sprint(*params.%s))\n", param.Name, field)
+			}
+			fmt.Fprintf(b, "\t\t}\n")
+		}
+		fmt.Fprintf(b, "\t}\n")
+	}
+	body := "nil"
+	if info.bodyType != "" {
+		body = "body"
+	}
+	fmt.Fprintf(b, "\tresp, err := c.do(ctx, %q, path, query, %s)\n", info.method, body)
+	fmt.Fprintf(b, "\tif err != nil {\n\t\treturn %s, err\n\t}\n", zero)
+
+	switch info.kind {
+	case "json":
+		if info.result.Ref != "" {
+			fmt.Fprintf(b, "\tresult := new(%s)\n", goType(info.result))
+		} else {
+			fmt.Fprintf(b, "\tvar result %s\n", resultType)
+		}
+		target := "&result"
+		if info.result.Ref != "" {
+			target = "result"
+		}
+		fmt.Fprintf(b, "\tif err = decodeJSONResponse(resp, %s, []int{%s}); err != nil && !isStatusError(err) {\n", target, strings.Join(info.resultFor, ", "))
+		fmt.Fprintf(b, "\t\treturn nil, err\n\t}\n")
+		fmt.Fprintf(b, "\treturn result, err\n")
+	case "text":
+		fmt.Fprintf(b, "\treturn decodeTextResponse(resp)\n")
+	default:
+		fmt.Fprintf(b, "\tif err = checkStreamResponse(resp); err != nil {\n\t\treturn nil, err\n\t}\n")
+		fmt.Fprintf(b, "\treturn resp, nil\n")
+	}
+	fmt.Fprintf(b, "}\n\n")
+}
+
+func generate(doc *Document) ([]byte, error) {
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "// Code generated by apiclient/gen from openapi.json; DO NOT EDIT.\n\n")
+	fmt.Fprintf(b, "package apiclient\n\n")
+	fmt.Fprintf(b, "import (\n\t\"context\"\n\t\"fmt\"\n\t\"net/http\"\n\t\"net/url\"\n)\n\n")
+	fmt.Fprintf(b, "// APIVersion the version of the OpenAPI document the client is generated from\n")
+	fmt.Fprintf(b, "const APIVersion = %q\n\n", doc.Info.Version)
+	fmt.Fprintf(b, "// make sure the imports are used even if no operation needs them\n")
+	fmt.Fprintf(b, "var (\n\t_ = fmt.Sprint\n\t_ http.Response\n)\n\n")
+
+	generateSchemas(b, doc)
+	for _, path := range sortedKeys(doc.Paths) {
+		for _, method := range sortedKeys(doc.Paths[path]) {
+			op := doc.Paths[path][method]
+			info := analyzeOperation(doc, path, method, op)
+			if info.kind == "" {
+				// the operation without successful http response like WebSocket upgrading
+				continue
+			}
+			generateOperation(b, info)
+		}
+	}
+	return format.Source(b.Bytes())
+}
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "Usage: gen <openapi.json> <output.go>")
+		os.Exit(2)
+	}
+	b, err := os.ReadFile(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	var doc Document
+	if err = json.Unmarshal(b, &doc); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	src, err := generate(&doc)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err = os.WriteFile(os.Args[2], src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
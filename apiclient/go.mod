@@ -0,0 +1,3 @@
+module github.com/ochinchina/supervisord/apiclient
+
+go 1.23.0
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ochinchina/supervisord/config"
+	"github.com/ochinchina/supervisord/process"
+	log "github.com/sirupsen/logrus"
+)
+
+// the clock ticks per second of utime and stime in /proc/<pid>/stat. It is USER_HZ which is fixed
+// to 100 by the Linux ABI, the CPU signal is only supported on Linux
+const clockTicksPerSecond = 100
+
+// autoscaler changes the numprocs of a program between autoscale_min and autoscale_max by a scale
+// signal. It is enabled by autoscale_max in [program:x] section with one of the signals:
+//
+//	autoscale_command - a command printing a number, e.g. the depth of a queue
+//	autoscale_http - an url returning a number in the body
+//	autoscale_cpu - if true, the sum of the CPU percent of all the instances
+//
+// The numprocs is set to ceil(signal / autoscale_target) every autoscale_interval seconds (default 15),
+// autoscale_scale_up_cooldown (default 60) and autoscale_scale_down_cooldown (default 300) are the
+// seconds to wait after the last scaling before scaling up or down again. The numprocs set by the
+// autoscaler and the time of the last scaling are kept across reloads
+type autoscaler struct {
+	supervisor   *Supervisor
+	program      string
+	min          int
+	max          int
+	target       float64
+	command      string
+	url          string
+	cpu          bool
+	interval     time.Duration
+	upCooldown   time.Duration
+	downCooldown time.Duration
+	env          []string
+	dir          string
+	lastScale    time.Time
+	// the cpu ticks of the instances in the last sample
+	cpuTicks   map[int]uint64
+	cpuSampled time.Time
+	done       chan struct{}
+}
+
+// create the autoscaler of the program, nil if autoscaling is not configured
+func newAutoscaler(s *Supervisor, program string, entry *config.Entry) (*autoscaler, error) {
+	max := entry.GetInt("autoscale_max", 0)
+	if max <= 0 {
+		return nil, nil
+	}
+	a := &autoscaler{
+		supervisor:   s,
+		program:      program,
+		min:          entry.GetInt("autoscale_min", 1),
+		max:          max,
+		command:      entry.GetString("autoscale_command", ""),
+		url:          entry.GetString("autoscale_http", ""),
+		cpu:          entry.GetBool("autoscale_cpu", false),
+		interval:     time.Duration(entry.GetInt("autoscale_interval", 15)) * time.Second,
+		upCooldown:   time.Duration(entry.GetInt("autoscale_scale_up_cooldown", 60)) * time.Second,
+		downCooldown: time.Duration(entry.GetInt("autoscale_scale_down_cooldown", 300)) * time.Second,
+		env:          append(os.Environ(), entry.GetEnv("environment")...),
+		dir:          entry.GetStringExpression("directory", ""),
+		done:         make(chan struct{}),
+	}
+	target, err := strconv.ParseFloat(entry.GetString("autoscale_target", ""), 64)
+	if err != nil || target <= 0 {
+		return nil, fmt.Errorf("autoscale_target must be a positive number")
+	}
+	a.target = target
+	if a.min < 0 || a.min > a.max {
+		return nil, fmt.Errorf("autoscale_min must be between 0 and autoscale_max")
+	}
+	signals := 0
+	for _, configured := range []bool{a.command != "", a.url != "", a.cpu} {
+		if configured {
+			signals++
+		}
+	}
+	if signals != 1 {
+		return nil, fmt.Errorf("exactly one of autoscale_command, autoscale_http and autoscale_cpu must be set")
+	}
+	if a.cpu && runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("autoscale_cpu is only supported on Linux")
+	}
+	if a.interval <= 0 {
+		a.interval = 15 * time.Second
+	}
+	return a, nil
+}
+
+// run the autoscaler until it is stopped
+func (a *autoscaler) run() {
+	log.WithFields(log.Fields{"program": a.program, "min": a.min, "max": a.max, "target": a.target}).Info("start the autoscaler")
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-time.After(a.interval):
+		}
+		a.evaluate()
+	}
+}
+
+// stop the autoscaler, the numprocs is not changed after it returns
+func (a *autoscaler) stop() {
+	close(a.done)
+}
+
+// read the scale signal and change the numprocs if needed
+func (a *autoscaler) evaluate() {
+	current, ok := a.supervisor.config.GetNumprocs(a.program)
+	if !ok {
+		return
+	}
+	desired := current
+	value, err := a.readSignal()
+	if err != nil {
+		log.WithFields(log.Fields{"program": a.program, log.ErrorKey: err}).Warn("fail to read the autoscale signal")
+	} else if value >= 0 {
+		desired = int(math.Ceil(value / a.target))
+	}
+	desired = max(a.min, min(a.max, desired))
+	if desired == current {
+		return
+	}
+	cooldown := a.upCooldown
+	if desired < current {
+		cooldown = a.downCooldown
+	}
+	// the lastScale is accessed with the lock of the supervisor because it is carried over to the
+	// autoscaler created on reload
+	scaled := false
+	_, err = a.supervisor.scaleProgramIf(a.program, desired, func() bool {
+		if !a.isRunning() || (!a.lastScale.IsZero() && time.Since(a.lastScale) < cooldown) {
+			return false
+		}
+		a.lastScale, scaled = time.Now(), true
+		return true
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"program": a.program, log.ErrorKey: err}).Error("fail to autoscale the program")
+	} else if scaled {
+		log.WithFields(log.Fields{"program": a.program, "signal": value, "from": current, "to": desired}).Info("autoscale the program")
+	}
+}
+
+// check if the autoscaler is not stopped
+func (a *autoscaler) isRunning() bool {
+	select {
+	case <-a.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// read the value of the scale signal, a negative value is returned if no value is available yet
+func (a *autoscaler) readSignal() (float64, error) {
+	if a.cpu {
+		return a.readCPU(), nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), a.interval)
+	defer cancel()
+	var out []byte
+	var err error
+	if a.command != "" {
+		out, err = a.runCommand(ctx)
+	} else {
+		out, err = a.getURL(ctx)
+	}
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("no number is returned")
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("fail to parse %q as a number", fields[0])
+	}
+	return math.Max(value, 0), nil
+}
+
+func (a *autoscaler) runCommand(ctx context.Context) ([]byte, error) {
+	args, err := process.ParseCommand(a.command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty autoscale command")
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = a.env
+	cmd.Dir = a.dir
+	return cmd.Output()
+}
+
+func (a *autoscaler) getURL(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("http status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 4096))
+}
+
+// get the sum of the CPU percent of the instances since the last sample, -1 on the first sample
+func (a *autoscaler) readCPU() float64 {
+	now := time.Now()
+	ticks := make(map[int]uint64)
+	var used uint64
+	for _, name := range a.supervisor.config.GetProgramInstanceNames(a.program) {
+		proc := a.supervisor.procMgr.Find(name)
+		if proc == nil {
+			continue
+		}
+		pid := proc.GetPid()
+		if pid <= 0 {
+			continue
+		}
+		t, err := readProcessCPUTicks(pid)
+		if err != nil {
+			continue
+		}
+		ticks[pid] = t
+		if prev, ok := a.cpuTicks[pid]; ok && t >= prev {
+			used += t - prev
+		}
+	}
+	elapsed := now.Sub(a.cpuSampled)
+	first := a.cpuSampled.IsZero()
+	a.cpuTicks, a.cpuSampled = ticks, now
+	if first || elapsed <= 0 {
+		return -1
+	}
+	return float64(used) / clockTicksPerSecond / elapsed.Seconds() * 100
+}
+
+// read the utime and stime of the process from /proc/<pid>/stat
+func readProcessCPUTicks(pid int) (uint64, error) {
+	content, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+	// the process name in the 2nd field may contain spaces, the fields after it are counted from ')'
+	stat := string(content)
+	pos := strings.LastIndex(stat, ")")
+	if pos == -1 {
+		return 0, fmt.Errorf("invalid stat of process %d", pid)
+	}
+	fields := strings.Fields(stat[pos+1:])
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("invalid stat of process %d", pid)
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return utime + stime, nil
+}
+
+// stop the autoscalers of the previous configuration and start the ones configured now, the time
+// of the last scaling is carried over so the cooldowns are kept. It must be called with the lock
+func (s *Supervisor) startAutoscalers() {
+	lastScales := make(map[string]time.Time)
+	for _, a := range s.autoscalers {
+		a.stop()
+		lastScales[a.program] = a.lastScale
+	}
+	s.autoscalers = nil
+	for _, program := range s.config.GetProgramSectionNames() {
+		entry := s.config.GetProgramSection(program)
+		if entry == nil {
+			continue
+		}
+		a, err := newAutoscaler(s, program, entry)
+		if err != nil {
+			log.WithFields(log.Fields{"program": program, log.ErrorKey: err}).Error("invalid autoscale configuration")
+			continue
+		}
+		if a != nil {
+			a.lastScale = lastScales[program]
+			s.autoscalers = append(s.autoscalers, a)
+			go a.run()
+		}
+	}
+}
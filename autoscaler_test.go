@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ochinchina/supervisord/config"
+)
+
+// load the configuration in a temporary file
+func loadTestConfig(t *testing.T, conf string) *config.Config {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "supervisord.conf")
+	if err := os.WriteFile(file, []byte(conf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.NewConfig(file)
+	if _, _, err := cfg.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestNewAutoscaler(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    string
+		enabled bool
+		err     bool
+	}{
+		{"not configured", "", false, false},
+		{"command", "autoscale_max=3\nautoscale_target=10\nautoscale_command=echo 1\n", true, false},
+		{"no signal", "autoscale_max=3\nautoscale_target=10\n", false, true},
+		{"two signals", "autoscale_max=3\nautoscale_target=10\nautoscale_command=echo 1\nautoscale_http=http://localhost/\n", false, true},
+		{"no target", "autoscale_max=3\nautoscale_command=echo 1\n", false, true},
+		{"min over max", "autoscale_max=3\nautoscale_min=4\nautoscale_target=10\nautoscale_command=echo 1\n", false, true},
+		{"cpu", "autoscale_max=3\nautoscale_target=50\nautoscale_cpu=true\n", runtime.GOOS == "linux", runtime.GOOS != "linux"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := loadTestConfig(t, "[program:a]\ncommand=/bin/true\n"+test.conf)
+			a, err := newAutoscaler(nil, "a", cfg.GetProgramSection("a"))
+			if (err != nil) != test.err || (a != nil) != test.enabled {
+				t.Errorf("newAutoscaler() = %v, %v, want enabled %v, error %v", a, err, test.enabled, test.err)
+			}
+		})
+	}
+}
+
+func TestReadProcessCPUTicks(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("/proc/<pid>/stat is only available on Linux")
+	}
+	if _, err := readProcessCPUTicks(os.Getpid()); err != nil {
+		t.Errorf("readProcessCPUTicks() error = %v", err)
+	}
+	if _, err := readProcessCPUTicks(-1); err == nil {
+		t.Errorf("readProcessCPUTicks(-1) error = nil")
+	}
+}
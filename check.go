@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ochinchina/supervisord/config"
+	"github.com/ochinchina/supervisord/process"
+)
+
+// CheckCommand the check subcommand validates the configuration without starting supervisord
+type CheckCommand struct {
+	Strict bool `short:"s" long:"strict" description:"fail if there are warnings also"`
+}
+
+var checkCommand CheckCommand
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (c CheckCommand) Execute(args []string) error {
+	loadEnvFile()
+	if len(args) > 0 {
+		options.Configuration = args[0]
+	}
+	configFile, err := findSupervisordConf()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cfg := config.NewConfig(configFile)
+	diagnostics := append(cfg.Check(), checkProgramCommands(cfg, config.SeverityError)...)
+	cfg.SortDiagnostics(diagnostics)
+
+	errors, warnings := 0, 0
+	for _, d := range diagnostics {
+		fmt.Println(d.String())
+		if d.Severity == config.SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	fmt.Printf("%s: %d error(s), %d warning(s)\n", configFile, errors, warnings)
+	if errors > 0 || (c.Strict && warnings > 0) {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// check the commands of the programs and the event listeners can be executed. The program is
+// looked up in PATH like it is started, a relative path is relative to the directory of the program
+func checkProgramCommands(cfg *config.Config, severity config.Severity) []config.Diagnostic {
+	diagnostics := make([]config.Diagnostic, 0)
+	checked := make(map[string]bool)
+	for _, entry := range append(cfg.GetPrograms(), cfg.GetEventListeners()...) {
+		sectionName := entry.Name
+		if programName, ok := cfg.GetProgramOfProcess(entry.GetProgramName()); ok {
+			sectionName = "program:" + programName
+		}
+		args, err := process.GetCommandArgs(entry)
+		// a missing command is reported by the configuration
+		if err != nil || checked[sectionName+"\x00"+args[0]] {
+			continue
+		}
+		checked[sectionName+"\x00"+args[0]] = true
+		if err := checkExecutable(args[0], entry.GetStringExpression("directory", "")); err != nil {
+			diagnostics = append(diagnostics, cfg.NewDiagnostic(sectionName, "command", severity, "%v", err))
+		}
+	}
+	return diagnostics
+}
+
+// check if the program is an executable file
+func checkExecutable(program string, dir string) error {
+	path := program
+	if !strings.Contains(program, "/") {
+		found, err := exec.LookPath(program)
+		if err != nil {
+			return fmt.Errorf("%s is not found in PATH", program)
+		}
+		path = found
+	} else if !filepath.IsAbs(program) && dir != "" {
+		path = filepath.Join(dir, program)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%s does not exist", path)
+	}
+	if info.IsDir() || info.Mode()&0o111 == 0 {
+		return fmt.Errorf("%s is not executable", path)
+	}
+	return nil
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/ochinchina/supervisord/faults"
 )
 
 type ConfAPI struct {
@@ -25,20 +27,20 @@ func (ca *ConfAPI) CreateHandler() http.Handler {
 func (ca *ConfAPI) getProgramConfFile(writer http.ResponseWriter, request *http.Request) {
 	vars := mux.Vars(request)
 	if vars == nil {
-		writer.WriteHeader(http.StatusNotFound)
+		writeFault(writer, faults.NewFault(faults.BadName, "no program is provided"))
 		return
 	}
 
 	programName := vars["program"]
 	programConfigPath := getProgramConfigPath(programName, ca.supervisor)
 	if programConfigPath == "" {
-		writer.WriteHeader(http.StatusNotFound)
+		writeFault(writer, faults.NewFault(faults.NoFile, fmt.Sprintf("no configuration file of program %s", programName)))
 		return
 	}
 
 	b, err := readFile(programConfigPath)
 	if err != nil {
-		writer.WriteHeader(http.StatusNotFound)
+		writeFault(writer, faults.NewFault(faults.NoFile, err.Error()))
 		return
 	}
 
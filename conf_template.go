@@ -19,8 +19,8 @@ password=thepassword
 
 [supervisord]
 logfile=%(here)s/supervisord.log
-logfileMaxbytes=50MB
-logfileBackups=10
+logfile_maxbytes=50MB
+logfile_backups=10
 loglevel=info
 pidfile=%(here)s/supervisord.pid
 #umask=not support
@@ -34,6 +34,7 @@ pidfile=%(here)s/supervisord.pid
 #strip_ansi=not support
 #environment=not support
 identifier=supervisor
+shutdown_timeout=0
 
 [program:x]
 command=/bin/cat
@@ -65,7 +66,7 @@ environment=KEY="val",KEY2="val2"
 envFiles=global.env,prod.env
 directory=/tmp
 #umask=not support
-serverurl=AUTO
+#serverurl=not support
 
 [include]
 files=/an/absolute/filename.conf /an/absolute/*.conf foo.conf config??.conf
@@ -104,7 +105,7 @@ environment=KEY="val",KEY2="val2"
 envFiles=global.env,prod.env
 directory=/tmp
 #umask=not support
-serverurl=AUTO
+#serverurl=not support
 buffer_size=10240
 events=PROCESS_STATE
 #result_handler=not support
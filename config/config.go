@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -20,6 +21,8 @@ type Entry struct {
 	Group     string
 	Name      string
 	keyValues map[string]string
+	// the x of the [program:x] section the instance is created from
+	sectionProgram string
 }
 
 // IsProgram returns true if this is a program section
@@ -35,6 +38,15 @@ func (c *Entry) GetProgramName() string {
 	return ""
 }
 
+// GetSectionProgramName returns the name x of the [program:x] section the program is created
+// from, it is different from the program name for the instances of a program with numprocs
+func (c *Entry) GetSectionProgramName() string {
+	if c.sectionProgram != "" {
+		return c.sectionProgram
+	}
+	return c.GetProgramName()
+}
+
 // IsEventListener returns true if this section is for event listener
 func (c *Entry) IsEventListener() bool {
 	return strings.HasPrefix(c.Name, "eventlistener:")
@@ -87,18 +99,38 @@ type Config struct {
 	configFile string
 	// mapping between the section name and configuration entry
 	entries map[string]*Entry
+	// the sections of the programs to create the instances when numprocs is changed
+	programTemplates map[string]*programTemplate
+	// the numprocs of the programs changed at runtime and kept across reloads
+	numprocsOverrides map[string]int
+	// the file to save the numprocsOverrides
+	numprocsFile string
+	// the numprocs of the programs set by their autoscalers, kept across reloads in memory only
+	autoscaledNumprocs map[string]int
+	// the directory to save the programs added at runtime
+	programsDir string
+	// the files and lines of the loaded sections and keys
+	locations *iniLocations
 
 	ProgramGroup *ProcessGroup
 }
 
 // NewEntry creates configuration entry
 func NewEntry(configDir string) *Entry {
-	return &Entry{configDir, "", "", make(map[string]string)}
+	return &Entry{ConfigDir: configDir, keyValues: make(map[string]string)}
 }
 
 // NewConfig creates Config object
 func NewConfig(configFile string) *Config {
-	return &Config{configFile, make(map[string]*Entry), NewProcessGroup()}
+	return &Config{
+		configFile:         configFile,
+		entries:            make(map[string]*Entry),
+		programTemplates:   make(map[string]*programTemplate),
+		numprocsOverrides:  make(map[string]int),
+		autoscaledNumprocs: make(map[string]int),
+		locations:          newIniLocations(),
+		ProgramGroup:       NewProcessGroup(),
+	}
 }
 
 // create a new entry or return the already-exist entry
@@ -112,19 +144,117 @@ func (c *Config) createEntry(name string, configDir string) *Entry {
 	return entry
 }
 
-// Load the configuration and return loaded programs
-func (c *Config) Load() ([]string, error) {
+// Load the configuration and return the loaded programs and the existing programs whose settings
+// are changed. The current configuration is kept if the loaded configuration is invalid
+func (c *Config) Load() ([]string, []string, error) {
+	loaded, loadedPrograms, diagnostics := c.load()
+	logDiagnostics(diagnostics)
+	if err := diagnosticsError(diagnostics); err != nil {
+		return nil, nil, err
+	}
+	changedPrograms := c.apply(loaded)
+	return loadedPrograms, changedPrograms, nil
+}
+
+// Check loads the configuration like Load even if it is invalid, and returns the problems found in it
+// sorted by the files and the lines
+func (c *Config) Check() []Diagnostic {
+	loaded, _, diagnostics := c.load()
+	c.apply(loaded)
+	return diagnostics
+}
+
+// read the configuration file, the included files and the files in programs_dir
+func (c *Config) read() (*ini.Ini, *iniLocations, string, []Diagnostic) {
 	myini := ini.NewIni()
-	c.ProgramGroup = NewProcessGroup()
-	log.WithFields(log.Fields{"file": c.configFile}).Info("load configuration from file")
-	myini.LoadFile(c.configFile)
+	locations := newIniLocations()
+	diagnostics := make([]Diagnostic, 0)
+	readFile := func(file string) {
+		log.WithFields(log.Fields{"file": file}).Info("load configuration from file")
+		if isStructuredFile(file) {
+			diagnostics = append(diagnostics, loadStructuredFile(file, myini, locations)...)
+			return
+		}
+		diagnostics = append(diagnostics, locations.scanFile(file)...)
+		myini.LoadFile(file)
+	}
 
-	includeFiles := c.getIncludeFiles(myini)
-	for _, f := range includeFiles {
-		log.WithFields(log.Fields{"file": f}).Info("load configuration from file")
-		myini.LoadFile(f)
+	readFile(c.configFile)
+	for _, f := range c.getIncludeFiles(myini) {
+		readFile(f)
 	}
-	return c.parse(myini), nil
+	programsDir := c.getProgramsDir(myini)
+	for _, f := range getProgramsDirFiles(programsDir) {
+		readFile(f)
+	}
+	return myini, locations, programsDir, diagnostics
+}
+
+// read, validate and parse the configuration into a new Config
+func (c *Config) load() (*Config, []string, []Diagnostic) {
+	myini, locations, programsDir, diagnostics := c.read()
+	diagnostics = append(diagnostics, c.validate(myini, locations)...)
+
+	loaded := NewConfig(c.configFile)
+	loaded.locations = locations
+	loaded.programsDir = programsDir
+	loaded.numprocsFile = c.getNumprocsFile(myini)
+	loaded.numprocsOverrides = c.loadNumprocsOverrides(loaded.numprocsFile)
+	for programName, numProcs := range c.autoscaledNumprocs {
+		loaded.autoscaledNumprocs[programName] = numProcs
+	}
+	loadedPrograms := loaded.parse(myini)
+	diagnostics = append(diagnostics, validateDependsOn(loaded.GetPrograms(), locations)...)
+	loaded.SortDiagnostics(diagnostics)
+	return loaded, loadedPrograms, diagnostics
+}
+
+// apply the loaded configuration and return the existing programs whose settings are changed.
+// The existing entries are updated in place because they are referenced by the created processes
+func (c *Config) apply(loaded *Config) []string {
+	changedPrograms := make([]string, 0)
+	for name, entry := range loaded.entries {
+		existing, ok := c.entries[name]
+		if !ok {
+			c.entries[name] = entry
+			continue
+		}
+		if existing.IsProgram() && existing.isChanged(entry) {
+			changedPrograms = append(changedPrograms, name)
+		}
+		existing.Name = entry.Name
+		existing.Group = entry.Group
+		existing.keyValues = entry.keyValues
+		existing.sectionProgram = entry.sectionProgram
+	}
+	c.ProgramGroup = loaded.ProgramGroup
+	c.programTemplates = loaded.programTemplates
+	c.numprocsOverrides = loaded.numprocsOverrides
+	c.autoscaledNumprocs = loaded.autoscaledNumprocs
+	c.numprocsFile = loaded.numprocsFile
+	c.programsDir = loaded.programsDir
+	c.locations = loaded.locations
+	sort.Strings(changedPrograms)
+	return changedPrograms
+}
+
+// check if the settings of the entry are changed. The numprocs of an instance is ignored because
+// it is changed by scaling the program without affecting the instance
+func (c *Entry) isChanged(other *Entry) bool {
+	if c.Group != other.Group || c.ConfigDir != other.ConfigDir {
+		return true
+	}
+	for k, v := range c.keyValues {
+		if otherValue, ok := other.keyValues[k]; k != "numprocs" && (!ok || otherValue != v) {
+			return true
+		}
+	}
+	for k := range other.keyValues {
+		if _, ok := c.keyValues[k]; k != "numprocs" && !ok {
+			return true
+		}
+	}
+	return false
 }
 
 func (c *Config) getIncludeFiles(cfg *ini.Ini) []string {
@@ -221,9 +351,6 @@ func (c *Config) GetUnixHTTPServer() (*Entry, bool) {
 // GetSupervisord returns "supervisord" configuration section
 func (c *Config) GetSupervisord() (*Entry, bool) {
 	entry, ok := c.entries["supervisord"]
-	if entry == nil {
-		panic("supervisord section is missing")
-	}
 	return entry, ok
 }
 
@@ -233,6 +360,12 @@ func (c *Config) GetInetHTTPServer() (*Entry, bool) {
 	return entry, ok
 }
 
+// GetSupervisorctl returns "supervisorctl" configuration section
+func (c *Config) GetSupervisorctl() (*Entry, bool) {
+	entry, ok := c.entries["supervisorctl"]
+	return entry, ok
+}
+
 // GetEntries returns configuration entries by filter
 func (c *Config) GetEntries(filterFunc func(entry *Entry) bool) []*Entry {
 	result := make([]*Entry, 0)
@@ -322,23 +455,43 @@ func (c *Entry) GetInt(key string, defValue int) int {
 }
 
 func parseEnv(s string) *map[string]string {
+	result, _ := parseEnvVariables(s)
+	return result
+}
+
+// parse the variables like A="env 1",B=2, the variables without a name are skipped and reported
+// by the error
+func parseEnvVariables(s string) (*map[string]string, error) {
 	result := make(map[string]string)
+	unnamed := make([]string, 0)
+	set := func(key string, value string) {
+		if key = strings.TrimSpace(key); key != "" {
+			result[key] = value
+		} else {
+			unnamed = append(unnamed, strconv.Quote("="+value))
+		}
+	}
 	start := 0
 	n := len(s)
 	var i int
-	for {
+	for strings.TrimSpace(s[start:]) != "" {
 		// find the '='
 		for i = start; i < n && s[i] != '='; {
 			i++
 		}
 		key := s[start:i]
 		start = i + 1
+		if start >= n {
+			// the last variable has an empty value or no '='
+			set(key, "")
+			break
+		}
 		if s[start] == '"' {
 			for i = start + 1; i < n && s[i] != '"'; {
 				i++
 			}
 			if i < n {
-				result[strings.TrimSpace(key)] = strings.TrimSpace(s[start+1 : i])
+				set(key, strings.TrimSpace(s[start+1:i]))
 			}
 			if i+1 < n && s[i+1] == ',' {
 				start = i + 2
@@ -350,16 +503,18 @@ func parseEnv(s string) *map[string]string {
 				i++
 			}
 			if i < n {
-				result[strings.TrimSpace(key)] = strings.TrimSpace(s[start:i])
+				set(key, strings.TrimSpace(s[start:i]))
 				start = i + 1
 			} else {
-				result[strings.TrimSpace(key)] = strings.TrimSpace(s[start:])
+				set(key, strings.TrimSpace(s[start:]))
 				break
 			}
 		}
 	}
-
-	return &result
+	if len(unnamed) > 0 {
+		return &result, fmt.Errorf("variable without a name %s", strings.Join(unnamed, ", "))
+	}
+	return &result, nil
 }
 
 func parseEnvFiles(s string) *map[string]string {
@@ -392,19 +547,18 @@ func parseEnvFiles(s string) *map[string]string {
 // GetEnv returns slice of strings with keys separated from values by single "=". An environment string example:
 //
 //	environment = A="env 1",B="this is a test"
+//
+// the variables can be declared by the indexed keys also, e.g. environment[A]=env 1
 func (c *Entry) GetEnv(key string) []string {
-	value, ok := c.keyValues[key]
 	result := make([]string, 0)
 
-	if ok {
-		for k, v := range *parseEnv(value) {
-			tmp, err := NewStringExpression("program_name", c.GetProgramName(),
-				"process_num", c.GetString("process_num", "0"),
-				"group_name", c.GetGroupName(),
-				"here", c.ConfigDir).Eval(fmt.Sprintf("%s=%s", k, v))
-			if err == nil {
-				result = append(result, tmp)
-			}
+	for k, v := range getEnvironment(key, c.keyValues) {
+		tmp, err := NewStringExpression("program_name", c.GetProgramName(),
+			"process_num", c.GetString("process_num", "0"),
+			"group_name", c.GetGroupName(),
+			"here", c.ConfigDir).Eval(fmt.Sprintf("%s=%s", k, v))
+		if err == nil {
+			result = append(result, tmp)
 		}
 	}
 
@@ -569,67 +723,92 @@ func (c *Config) parseProgram(cfg *ini.Ini) []string {
 			if err != nil {
 				numProcs = 1
 			}
-			procName, err := section.GetValue("process_name")
-			if numProcs > 1 {
-				if err != nil || !strings.Contains(procName, "%(process_num)") {
-					log.WithFields(log.Fields{
-						"numprocs":     numProcs,
-						"process_name": procName,
-					}).Error("no process_num in process name")
-				}
+			configNumProcs := numProcs
+			if override, ok := c.numprocsOverrides[programName]; ok && prefix == "program:" {
+				numProcs = override
+			}
+			if autoscaled, ok := c.getAutoscaledNumprocs(section); ok && prefix == "program:" {
+				numProcs = autoscaled
 			}
+			procName, err := section.GetValue("process_name")
 			originalProcName := programName
 			if err == nil {
 				originalProcName = procName
 			}
 
-			originalCmd := section.GetValueWithDefault("command", "")
-
+			t := &programTemplate{
+				section:        section,
+				prefix:         prefix,
+				programName:    programName,
+				command:        section.GetValueWithDefault("command", ""),
+				processName:    originalProcName,
+				numProcs:       numProcs,
+				configNumProcs: configNumProcs,
+			}
+			c.programTemplates[programName] = t
 			for i := 1; i <= numProcs; i++ {
-				envs := NewStringExpression("program_name", programName,
-					"process_num", fmt.Sprintf("%d", i),
-					"group_name", c.ProgramGroup.GetGroup(programName, programName),
-					"here", c.GetConfigFileDir())
-				envValue, err := section.GetValue("environment")
-				if err == nil {
-					for k, v := range *parseEnv(envValue) {
-						envs.Add(fmt.Sprintf("ENV_%s", k), v)
-					}
+				if procName, err := c.addProgramInstance(t, i); err == nil {
+					loadedPrograms = append(loadedPrograms, procName)
 				}
-				cmd, err := envs.Eval(originalCmd)
-				if err != nil {
-					log.WithFields(log.Fields{
-						log.ErrorKey: err,
-						"program":    programName,
-					}).Error("get envs failed")
-					continue
-				}
-				section.Add("command", cmd)
-
-				procName, err := envs.Eval(originalProcName)
-				if err != nil {
-					log.WithFields(log.Fields{
-						log.ErrorKey: err,
-						"program":    programName,
-					}).Error("get envs failed")
-					continue
-				}
-
-				section.Add("process_name", procName)
-				section.Add("numprocs_start", fmt.Sprintf("%d", i-1))
-				section.Add("process_num", fmt.Sprintf("%d", i))
-				entry := c.createEntry(procName, c.GetConfigFileDir())
-				entry.parse(section)
-				entry.Name = prefix + procName
-				group := c.ProgramGroup.GetGroup(programName, programName)
-				entry.Group = group
-				loadedPrograms = append(loadedPrograms, procName)
 			}
 		}
 	}
 	return loadedPrograms
 }
 
+// the expression to evaluate the command and process_name of the ith instance of the program
+func (c *Config) programExpression(t *programTemplate, i int) *StringExpression {
+	envs := NewStringExpression("program_name", t.programName,
+		"process_num", fmt.Sprintf("%d", i),
+		"group_name", c.ProgramGroup.GetGroup(t.programName, t.programName),
+		"here", c.GetConfigFileDir())
+	for k, v := range getEnvironment("environment", sectionKeyValues(t.section)) {
+		envs.Add(fmt.Sprintf("ENV_%s", k), v)
+	}
+	return envs
+}
+
+// create the configuration entry of the ith instance of the program, returns the process name
+func (c *Config) addProgramInstance(t *programTemplate, i int) (string, error) {
+	section := t.section
+	envs := c.programExpression(t, i)
+	cmd, err := envs.Eval(t.command)
+	if err != nil {
+		log.WithFields(log.Fields{
+			log.ErrorKey: err,
+			"program":    t.programName,
+		}).Error("get envs failed")
+		return "", err
+	}
+	// the command may be declared as an argv array by command[0], command[1]...
+	if section.HasKey("command") {
+		section.Add("command", cmd)
+	}
+
+	procName, err := envs.Eval(t.processName)
+	if err != nil {
+		log.WithFields(log.Fields{
+			log.ErrorKey: err,
+			"program":    t.programName,
+		}).Error("get envs failed")
+		return "", err
+	}
+
+	section.Add("process_name", procName)
+	section.Add("numprocs_start", fmt.Sprintf("%d", i-1))
+	section.Add("process_num", fmt.Sprintf("%d", i))
+	if section.HasKey("numprocs") || t.numProcs != 1 {
+		section.Add("numprocs", fmt.Sprintf("%d", t.numProcs))
+	}
+	entry := c.createEntry(procName, c.GetConfigFileDir())
+	entry.parse(section)
+	entry.Name = t.prefix + procName
+	entry.sectionProgram = t.programName
+	group := c.ProgramGroup.GetGroup(t.programName, t.programName)
+	entry.Group = group
+	return procName, nil
+}
+
 // RemoveProgram removes program entry by its name
 func (c *Config) RemoveProgram(programName string) {
 	delete(c.entries, programName)
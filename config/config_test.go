@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		want map[string]string
+		// the expected error, empty if all the variables have a name
+		err string
+	}{
+		{"empty", "", map[string]string{}, ""},
+		{"variables", "A=1,B=2", map[string]string{"A": "1", "B": "2"}, ""},
+		{"quoted value", `A="x, y",B=2`, map[string]string{"A": "x, y", "B": "2"}, ""},
+		{"spaces", " A = 1 , B = 2 ", map[string]string{"A": "1", "B": "2"}, ""},
+		{"empty values", "A=,B=", map[string]string{"A": "", "B": ""}, ""},
+		{"trailing comma", "A=1,", map[string]string{"A": "1"}, ""},
+		{"without a name", "A=1,=x,B=2", map[string]string{"A": "1", "B": "2"}, `variable without a name "=x"`},
+		{"quoted without a name", `="x",B=2`, map[string]string{"B": "2"}, `variable without a name "=x"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseEnvVariables(test.env)
+			if fmt.Sprint(*got) != fmt.Sprint(test.want) {
+				t.Errorf("parseEnvVariables(%q) = %v, want %v", test.env, *got, test.want)
+			}
+			if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("parseEnvVariables(%q) error = %v, want %q", test.env, err, test.err)
+			}
+		})
+	}
+}
+
+func TestValidateEnvironment(t *testing.T) {
+	_, diagnostics := loadTestConfig(t, "[supervisord]\n[program:a]\ncommand=/bin/true\nenvironment=A=1,=x,B=2\n")
+	if len(diagnostics) != 1 || diagnostics[0].Key != "environment" || diagnostics[0].Line != 4 {
+		t.Errorf("diagnostics = %v, want the variable without a name at line 4", diagnostics)
+	}
+}
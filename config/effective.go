@@ -0,0 +1,213 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// the sources of the effective values of a program
+const (
+	// SourceFile the key is set in the [program:x] section
+	SourceFile = "file"
+	// SourceInherited the key is inherited from the [program-default] section
+	SourceInherited = "inherited"
+	// SourceExpanded the key is set for every instance when numprocs is expanded
+	SourceExpanded = "expanded"
+	// SourceRuntime the key is set by adding or scaling the program at runtime
+	SourceRuntime = "runtime"
+	// SourceDefault the key is not set and the built-in default is used
+	SourceDefault = "default"
+)
+
+// the built-in defaults of the program keys used when they are not set, the keys without a
+// default or only used when a feature is enabled are not listed
+var programKeyDefaults = map[string]string{
+	"autorestart":             "unexpected",
+	"autostart":               "true",
+	"exitcodes":               "0,2",
+	"killasgroup":             "false",
+	"killwaitsecs":            "2",
+	"numprocs":                "1",
+	"priority":                "999",
+	"redirect_stderr":         "false",
+	"restart_limit_action":    "none",
+	"startretries":            "3",
+	"startsecs":               "1",
+	"stderr_logfile":          "/dev/null",
+	"stderr_logfile_backups":  "10",
+	"stderr_logfile_maxbytes": "50MB",
+	"stdout_logfile":          "/dev/null",
+	"stdout_logfile_backups":  "10",
+	"stdout_logfile_maxbytes": "50MB",
+	"stopasgroup":             "false",
+	"stopsignal":              "TERM",
+	"stopwaitsecs":            "10",
+}
+
+// EffectiveValue the final value of a key of a program and where it comes from
+type EffectiveValue struct {
+	Key string `json:"key"`
+	// the value after the %(var)s expressions are evaluated
+	Value string `json:"value"`
+	// the value in the configuration if it is different from Value
+	Raw    string `json:"raw,omitempty"`
+	Source string `json:"source"`
+	// the section, the file and the line where the key is set
+	Section string `json:"section,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+}
+
+// EffectiveProgram the effective configuration of a process created from a [program:x] or
+// [eventlistener:x] section
+type EffectiveProgram struct {
+	Name    string           `json:"name"`
+	Section string           `json:"section"`
+	Group   string           `json:"group"`
+	Values  []EffectiveValue `json:"values"`
+}
+
+// GetEffectivePrograms returns the effective configuration of all the processes sorted by their
+// names. If names is not empty, only the processes or the programs with the names are returned
+func (c *Config) GetEffectivePrograms(names ...string) []EffectiveProgram {
+	programNames := make([]string, 0, len(c.programTemplates))
+	for programName := range c.programTemplates {
+		programNames = append(programNames, programName)
+	}
+	sort.Strings(programNames)
+
+	result := make([]EffectiveProgram, 0)
+	for _, programName := range programNames {
+		t := c.programTemplates[programName]
+		for _, procName := range c.GetProgramInstanceNames(programName) {
+			entry, ok := c.entries[procName]
+			if !ok || (len(names) > 0 && !inNames(names, programName, procName)) {
+				continue
+			}
+			result = append(result, c.getEffectiveProgram(t, procName, entry))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func inNames(names []string, programName string, procName string) bool {
+	for _, name := range names {
+		if name == programName || name == procName {
+			return true
+		}
+	}
+	return false
+}
+
+// get the effective values of the instance of the program
+func (c *Config) getEffectiveProgram(t *programTemplate, procName string, entry *Entry) EffectiveProgram {
+	sectionName := t.prefix + t.programName
+	program := EffectiveProgram{Name: procName, Section: sectionName, Group: entry.Group, Values: make([]EffectiveValue, 0)}
+	for key, value := range programKeyDefaults {
+		if _, ok := entry.keyValues[key]; !ok {
+			program.Values = append(program.Values, EffectiveValue{Key: key, Value: value, Source: SourceDefault})
+		}
+	}
+	if _, ok := entry.keyValues["buffer_size"]; !ok && t.prefix == "eventlistener:" {
+		program.Values = append(program.Values, EffectiveValue{Key: "buffer_size", Value: "100", Source: SourceDefault})
+	}
+
+	for key, raw := range entry.keyValues {
+		// numprocs_start is not supported, the value set for every instance is not a setting
+		if key == "numprocs_start" {
+			continue
+		}
+		switch key {
+		case "command":
+			raw = t.command
+		case "process_name":
+			raw = t.processName
+		}
+		value := EffectiveValue{Key: key, Value: entry.getEffectiveValue(key)}
+		if value.Value != raw {
+			value.Raw = raw
+		}
+		c.setValueSource(&value, t, raw)
+		program.Values = append(program.Values, value)
+	}
+	sort.Slice(program.Values, func(i, j int) bool {
+		return program.Values[i].Key < program.Values[j].Key
+	})
+	return program
+}
+
+// set where the value of the key in the section of the program comes from
+func (c *Config) setValueSource(value *EffectiveValue, t *programTemplate, raw string) {
+	sectionName := t.prefix + t.programName
+	scaled := t.numProcs != t.configNumProcs
+	programDefault, hasDefault := c.entries["program-default"]
+	loc, inSection := c.locations.keys[sectionName+"\x00"+value.Key]
+	switch {
+	case value.Key == "numprocs" && scaled:
+		value.Source = SourceRuntime
+	case value.Key == "process_num":
+		value.Source = SourceExpanded
+	case inSection:
+		value.Source, value.Section, value.File, value.Line = SourceFile, sectionName, loc.file, loc.line
+	case hasDefault && programDefault.HasParameter(value.Key) && programDefault.keyValues[value.Key] == raw:
+		loc = c.locations.keys["program-default\x00"+value.Key]
+		value.Source, value.Section, value.File, value.Line = SourceInherited, "program-default", loc.file, loc.line
+	case value.Key == "process_name" || value.Key == "numprocs":
+		value.Source = SourceExpanded
+	default:
+		value.Source = SourceRuntime
+	}
+}
+
+// get the value of the key with the %(var)s expressions evaluated like they are read by the process
+func (c *Entry) getEffectiveValue(key string) string {
+	if key == "environment" {
+		// the variables declared by the indexed keys environment[A] are written with their own keys
+		env := make([]string, 0)
+		for _, variable := range c.GetEnv(key) {
+			name, value, _ := strings.Cut(variable, "=")
+			if _, ok := c.keyValues[fmt.Sprintf("%s[%s]", key, name)]; !ok {
+				env = append(env, fmt.Sprintf("%s=%s", name, quoteEnvValue(value)))
+			}
+		}
+		sort.Strings(env)
+		return strings.Join(env, ",")
+	}
+	return c.GetStringExpression(key, "")
+}
+
+// quote the value of a variable so it is read back by parseEnv, a value with '"' can't be quoted
+// and is only read back if it has no ','
+func quoteEnvValue(value string) string {
+	if strings.Contains(value, "\"") {
+		return value
+	}
+	return "\"" + value + "\""
+}
+
+// WriteINI writes the effective configuration as a section, the source of every value is written
+// in the comment after it
+func (p EffectiveProgram) WriteINI(w io.Writer) error {
+	prefix := p.Section[:strings.Index(p.Section, ":")+1]
+	if _, err := fmt.Fprintf(w, "[%s%s]\n; %s, group %s\n", prefix, p.Name, p.Section, p.Group); err != nil {
+		return err
+	}
+	for _, value := range p.Values {
+		source := value.Source
+		if value.File != "" {
+			source = fmt.Sprintf("%s %s:%d [%s]", value.Source, value.File, value.Line, value.Section)
+		}
+		if value.Raw != "" {
+			source = fmt.Sprintf("%s, from %s", source, value.Raw)
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s ; %s\n", value.Key, escapeIniValue(value.Value), source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+)
+
+// get the effective value of the key of the process, empty if it is not set
+func getTestEffectiveValue(t *testing.T, conf string, procName string, key string) string {
+	t.Helper()
+	loaded, diagnostics := loadTestConfig(t, conf)
+	if HasErrors(diagnostics) {
+		t.Fatal(diagnostics)
+	}
+	for _, program := range loaded.GetEffectivePrograms(procName) {
+		for _, value := range program.Values {
+			if value.Key == key {
+				return value.Value
+			}
+		}
+	}
+	return ""
+}
+
+func TestEffectiveEnvironment(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		want string
+	}{
+		{"empty values", "environment=A=,B=", `A="",B=""`},
+		{"sorted", "environment=Z=1,A=2", `A="2",Z="1"`},
+		{"comma", `environment=A="x, y"`, `A="x, y"`},
+		{"quote", `environment=A=x"y`, `A=x"y`},
+		{"indexed keys are not merged", "environment=A=1,B=2\nenvironment[B]=x,\"y\"", `A="1"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conf := "[supervisord]\n[program:a]\ncommand=/bin/true\n" + test.env + "\n"
+			got := getTestEffectiveValue(t, conf, "a", "environment")
+			if got != test.want {
+				t.Errorf("environment = %s, want %s", got, test.want)
+			}
+			// the dumped value is read back as the same variables
+			again := getTestEffectiveValue(t, "[supervisord]\n[program:a]\ncommand=/bin/true\nenvironment="+got+"\n", "a", "environment")
+			if again != got {
+				t.Errorf("environment read back = %s, want %s", again, got)
+			}
+		})
+	}
+}
+
+func TestEffectiveNumprocsStart(t *testing.T) {
+	conf := "[supervisord]\n[program:a]\ncommand=/bin/true\nnumprocs=2\nprocess_name=a_%(process_num)d\n"
+	for i, procName := range []string{"a_1", "a_2"} {
+		// numprocs_start is not supported and not dumped
+		if got := getTestEffectiveValue(t, conf, procName, "numprocs_start"); got != "" {
+			t.Errorf("numprocs_start of %s = %s, want it not dumped", procName, got)
+		}
+		if got := getTestEffectiveValue(t, conf, procName, "process_num"); got != fmt.Sprint(i+1) {
+			t.Errorf("process_num of %s = %s, want %d", procName, got, i+1)
+		}
+	}
+}
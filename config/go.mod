@@ -3,10 +3,13 @@ module github.com/ochinchina/supervisord/config
 go 1.23.0
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/hashicorp/go-envparse v0.1.0
 	github.com/ochinchina/go-ini v1.0.1
+	github.com/ochinchina/supervisord/signals v0.0.0-20230902082938-c2cae38b7454
 	github.com/ochinchina/supervisord/util v0.0.0-20230902082938-c2cae38b7454
 	github.com/sirupsen/logrus v1.9.3
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require golang.org/x/sys v0.24.0 // indirect
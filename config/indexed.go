@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ochinchina/go-ini"
+	log "github.com/sirupsen/logrus"
+)
+
+// The command and the environment of a program can be declared item by item with the indexed
+// keys, the values are used as they are without being split or unquoted:
+//
+//	command[0]=/bin/sh
+//	command[1]=-c
+//	command[2]=echo "a, b"; sleep 10
+//	environment[A]=x,"y"
+//	environment[B]=
+
+// the keys which can be declared with the indexed keys, true if the index is the position in an
+// argv array and false if the index is the name of a variable
+var indexedKeys = map[string]bool{
+	"command":     true,
+	"environment": false,
+}
+
+// split the indexed key like "command[0]" to the key and the index, false if it is not an indexed key
+func splitIndexedKey(name string) (string, string, bool) {
+	start := strings.Index(name, "[")
+	if start <= 0 || !strings.HasSuffix(name, "]") {
+		return "", "", false
+	}
+	return name[:start], name[start+1 : len(name)-1], true
+}
+
+// check the index of the indexed key, the index of an argv array is a non negative integer and the
+// index of a map is a variable name
+func checkIndex(key string, index string) error {
+	if indexedKeys[key] {
+		if i, err := strconv.Atoi(index); err != nil || i < 0 {
+			return fmt.Errorf("index %q is not a non negative integer", index)
+		}
+		return nil
+	}
+	if index == "" || strings.ContainsAny(index, "= \t") {
+		return fmt.Errorf("bad variable name %q", index)
+	}
+	return nil
+}
+
+// get the values of the indexed keys key[0], key[1]... in the order of their indexes, nil if there
+// is no indexed key
+func getIndexedArray(key string, keyValues map[string]string) []string {
+	indexes := make([]int, 0)
+	values := make(map[int]string)
+	for name, value := range keyValues {
+		if k, index, ok := splitIndexedKey(name); ok && k == key {
+			if i, err := strconv.Atoi(index); err == nil && i >= 0 {
+				indexes = append(indexes, i)
+				values[i] = value
+			}
+		}
+	}
+	if len(indexes) == 0 {
+		return nil
+	}
+	sort.Ints(indexes)
+	result := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		result = append(result, values[i])
+	}
+	return result
+}
+
+// check the indexes of the argv array declared by the indexed keys key[0], key[1]... are 0, 1, 2...
+// without a gap or a duplicate, returns the indexed key where the problem is found
+func checkIndexedArray(key string, keyValues map[string]string) (string, error) {
+	names := make(map[int][]string)
+	maxIndex := -1
+	for name := range keyValues {
+		k, index, ok := splitIndexedKey(name)
+		if !ok || k != key || checkIndex(key, index) != nil {
+			continue
+		}
+		i, _ := strconv.Atoi(index)
+		names[i] = append(names[i], name)
+		maxIndex = max(maxIndex, i)
+	}
+	for i := 0; i <= maxIndex; i++ {
+		if len(names[i]) > 1 {
+			sort.Strings(names[i])
+			return names[i][1], fmt.Errorf("%s is the same index as %s", names[i][1], names[i][0])
+		}
+		if len(names[i]) == 0 {
+			// report at the next declared index
+			next := i + 1
+			for len(names[next]) == 0 {
+				next++
+			}
+			return names[next][0], fmt.Errorf("%s[%d] is missing, the indexes must be 0, 1, 2... without a gap", key, i)
+		}
+	}
+	return "", nil
+}
+
+// get the variables declared by the key like A="env 1",B=2 and by the indexed keys key[A], the
+// indexed keys override the variables with the same name
+func getEnvironment(key string, keyValues map[string]string) map[string]string {
+	result := make(map[string]string)
+	if value, ok := keyValues[key]; ok && value != "" {
+		result = *parseEnv(value)
+	}
+	for name, value := range keyValues {
+		if k, index, ok := splitIndexedKey(name); ok && k == key && checkIndex(key, index) == nil {
+			result[index] = value
+		}
+	}
+	return result
+}
+
+// get the key/values of the section
+func sectionKeyValues(section *ini.Section) map[string]string {
+	keyValues := make(map[string]string)
+	for _, key := range section.Keys() {
+		keyValues[key.Name()] = key.ValueWithDefault("")
+	}
+	return keyValues
+}
+
+// hasIndexedKeys checks if the key is declared by the indexed keys
+func hasIndexedKeys(key string, keyValues map[string]string) bool {
+	for name := range keyValues {
+		if k, _, ok := splitIndexedKey(name); ok && k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// GetArgs returns the argv array declared by the indexed keys key[0], key[1]... with the %(var)s
+// expressions evaluated, nil if the key is not declared as an argv array
+func (c *Entry) GetArgs(key string) []string {
+	values := getIndexedArray(key, c.keyValues)
+	if values == nil {
+		return nil
+	}
+	hostName, err := os.Hostname()
+	if err != nil {
+		hostName = "Unknown"
+	}
+	envs := NewStringExpression("program_name", c.GetProgramName(),
+		"process_num", c.GetString("process_num", "0"),
+		"group_name", c.GetGroupName(),
+		"here", c.ConfigDir,
+		"host_node_name", hostName)
+	for k, v := range getEnvironment("environment", c.keyValues) {
+		envs.Add(fmt.Sprintf("ENV_%s", k), v)
+	}
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		arg, err := envs.Eval(value)
+		if err != nil {
+			log.WithFields(log.Fields{
+				log.ErrorKey: err,
+				"program":    c.GetProgramName(),
+				"key":        key,
+			}).Warn("unable to parse expression")
+			arg = value
+		}
+		result = append(result, arg)
+	}
+	return result
+}
+
+// GetCommand returns the command line of the program, the argv array declared by the indexed keys
+// is joined with the arguments quoted if needed
+func (c *Entry) GetCommand() string {
+	args := c.GetArgs("command")
+	if args == nil {
+		return c.GetStringExpression("command", "")
+	}
+	return joinArgs(args)
+}
+
+// join the arguments to a command line, the arguments with spaces or quotes are quoted
+func joinArgs(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\") {
+			arg = strconv.Quote(arg)
+		}
+		quoted = append(quoted, arg)
+	}
+	return strings.Join(quoted, " ")
+}
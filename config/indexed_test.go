@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGetIndexedArray(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyValues map[string]string
+		want      []string
+	}{
+		{"not indexed", map[string]string{"command": "/bin/true"}, nil},
+		{"ordered by index", map[string]string{"command[1]": "-c", "command[0]": "/bin/sh", "command[2]": "echo a, b"}, []string{"/bin/sh", "-c", "echo a, b"}},
+		{"sparse indexes", map[string]string{"command[10]": "b", "command[2]": "a"}, []string{"a", "b"}},
+		{"other key", map[string]string{"command[0]": "/bin/true", "environment[A]": "1"}, []string{"/bin/true"}},
+		{"bad index", map[string]string{"command[x]": "a", "command[-1]": "b"}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := getIndexedArray("command", test.keyValues)
+			if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", test.want) || (got == nil) != (test.want == nil) {
+				t.Errorf("getIndexedArray() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetEnvironment(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyValues map[string]string
+		want      map[string]string
+	}{
+		{"none", map[string]string{"command": "/bin/true"}, map[string]string{}},
+		{"variables", map[string]string{"environment": `A="x, y",B=2`}, map[string]string{"A": "x, y", "B": "2"}},
+		{"indexed", map[string]string{"environment[A]": `x,"y"`, "environment[B]": ""}, map[string]string{"A": `x,"y"`, "B": ""}},
+		{"indexed overrides", map[string]string{"environment": "A=1,B=2", "environment[A]": "3"}, map[string]string{"A": "3", "B": "2"}},
+		{"bad variable name", map[string]string{"environment[A B]": "1", "environment[]": "2"}, map[string]string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := getEnvironment("environment", test.keyValues)
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("getEnvironment() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckIndexedArray(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyValues map[string]string
+		// the indexed key and the error, empty if the indexes are valid
+		key string
+		err string
+	}{
+		{"not indexed", map[string]string{"command": "/bin/true"}, "", ""},
+		{"contiguous", map[string]string{"command[1]": "-c", "command[0]": "/bin/sh", "command[2]": "echo"}, "", ""},
+		{"gap", map[string]string{"command[0]": "/bin/echo", "command[2]": "a"}, "command[2]", "command[1] is missing, the indexes must be 0, 1, 2... without a gap"},
+		{"not from 0", map[string]string{"command[1]": "/bin/true"}, "command[1]", "command[0] is missing, the indexes must be 0, 1, 2... without a gap"},
+		{"duplicate", map[string]string{"command[0]": "/bin/echo", "command[1]": "a", "command[01]": "b"}, "command[1]", "command[1] is the same index as command[01]"},
+		{"bad index is reported by checkIndex", map[string]string{"command[0]": "/bin/true", "command[x]": "a"}, "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key, err := checkIndexedArray("command", test.keyValues)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if key != test.key || got != test.err {
+				t.Errorf("checkIndexedArray() = %s, %v, want %s, %s", key, err, test.key, test.err)
+			}
+		})
+	}
+}
+
+func TestValidateIndexedArray(t *testing.T) {
+	_, diagnostics := loadTestConfig(t, "[supervisord]\n[program:a]\ncommand[0]=/bin/echo\ncommand[2]=a\n")
+	got := errorLines(diagnostics)
+	if len(got) != 1 || got[0] != "4: command[1] is missing, the indexes must be 0, 1, 2... without a gap" {
+		t.Errorf("errors = %q, want the missing command[1] at line 4", got)
+	}
+}
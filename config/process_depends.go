@@ -0,0 +1,94 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// get the names of the programs in the depends_on parameter of the program
+func getDependsOn(entry *Entry) []string {
+	result := make([]string, 0)
+	for _, name := range strings.Split(entry.GetString("depends_on", ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// validateDependsOn checks the depends_on parameters of the programs, an error is reported at the
+// depends_on key if a program depends on an undefined program or the dependencies form a cycle.
+// The dependencies are between the [program:x] sections, an instance of a program with numprocs
+// is checked as its program
+func validateDependsOn(programs []*Entry, locations *iniLocations) []Diagnostic {
+	dependsOn := make(map[string][]string)
+	for _, entry := range programs {
+		if entry.IsProgram() {
+			dependsOn[entry.GetSectionProgramName()] = getDependsOn(entry)
+		}
+	}
+	names := make([]string, 0, len(dependsOn))
+	for name := range dependsOn {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	diagnostics := make([]Diagnostic, 0)
+	for _, name := range names {
+		for _, dependency := range dependsOn[name] {
+			if _, ok := dependsOn[dependency]; !ok {
+				diagnostics = append(diagnostics, locations.newDiagnostic("program:"+name, "depends_on", SeverityError,
+					"unknown program %s", dependency))
+			}
+		}
+	}
+	for _, cycle := range findDependsOnCycles(names, dependsOn) {
+		diagnostics = append(diagnostics, locations.newDiagnostic("program:"+cycle[0], "depends_on", SeverityError,
+			"cycle %s", strings.Join(cycle, " -> ")))
+	}
+	return diagnostics
+}
+
+// find the cycles in the dependency graph with depth-first search, every cycle is returned as
+// the path starting and ending with the same program
+func findDependsOnCycles(names []string, dependsOn map[string][]string) [][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	path := make([]string, 0)
+	cycles := make([][]string, 0)
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		path = append(path, name)
+		for _, dependency := range dependsOn[name] {
+			if _, ok := dependsOn[dependency]; !ok {
+				continue
+			}
+			switch state[dependency] {
+			case visiting:
+				for i, n := range path {
+					if n == dependency {
+						cycles = append(cycles, append(append([]string{}, path[i:]...), dependency))
+						break
+					}
+				}
+			case unvisited:
+				visit(dependency)
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+	}
+
+	for _, name := range names {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+	return cycles
+}
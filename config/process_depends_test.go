@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// write the configuration to a temporary file and load it without applying it
+func loadTestConfig(t *testing.T, conf string) (*Config, []Diagnostic) {
+	t.Helper()
+	return loadTestConfigFile(t, filepath.Join(t.TempDir(), "supervisord.conf"), conf)
+}
+
+// write the configuration to the file and load it without applying it, the format of the file is
+// decided by its extension
+func loadTestConfigFile(t *testing.T, file string, conf string) (*Config, []Diagnostic) {
+	t.Helper()
+	if err := os.WriteFile(file, []byte(conf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, _, diagnostics := NewConfig(file).load()
+	return loaded, diagnostics
+}
+
+func TestValidateDependsOn(t *testing.T) {
+	tests := []struct {
+		name string
+		conf string
+		// the lines and the messages of the depends_on errors
+		want []string
+	}{
+		{
+			name: "valid",
+			conf: "[program:db]\ncommand=/bin/true\n[program:app]\ncommand=/bin/true\ndepends_on=db\n",
+			want: []string{},
+		},
+		{
+			name: "dependency with numprocs",
+			conf: "[program:db]\ncommand=/bin/true\nnumprocs=2\nprocess_name=db_%(process_num)d\n" +
+				"[program:app]\ncommand=/bin/true\ndepends_on=db\n",
+			want: []string{},
+		},
+		{
+			name: "dependent with numprocs",
+			conf: "[program:db]\ncommand=/bin/true\n" +
+				"[program:app]\ncommand=/bin/true\nnumprocs=2\nprocess_name=app_%(process_num)d\ndepends_on=db\n",
+			want: []string{},
+		},
+		{
+			name: "unknown program",
+			conf: "[program:app]\ncommand=/bin/true\ndepends_on=db, cache\n",
+			want: []string{"3: unknown program db", "3: unknown program cache"},
+		},
+		{
+			name: "instance name is not a program",
+			conf: "[program:db]\ncommand=/bin/true\nnumprocs=2\nprocess_name=db_%(process_num)d\n" +
+				"[program:app]\ncommand=/bin/true\ndepends_on=db_1\n",
+			want: []string{"7: unknown program db_1"},
+		},
+		{
+			name: "cycle",
+			conf: "[program:a]\ncommand=/bin/true\ndepends_on=b\n[program:b]\ncommand=/bin/true\ndepends_on=a\n",
+			want: []string{"3: cycle a -> b -> a"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, diagnostics := loadTestConfig(t, test.conf)
+			got := make([]string, 0)
+			for _, d := range diagnostics {
+				if d.Key == "depends_on" {
+					got = append(got, fmt.Sprintf("%d: %s", d.Line, d.Message))
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("diagnostics = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -42,7 +42,7 @@ func (p *ProcessSorter) initDepends(programConfigs []*Entry) {
 	for _, config := range programConfigs {
 		if config.IsProgram() && config.HasParameter("depends_on") {
 			dependsOn := config.GetString("depends_on", "")
-			progName := config.GetProgramName()
+			progName := config.GetSectionProgramName()
 			for _, dependsOnProg := range strings.Split(dependsOn, ",") {
 				dependsOnProg = strings.TrimSpace(dependsOnProg)
 				if dependsOnProg != "" {
@@ -61,7 +61,7 @@ func (p *ProcessSorter) initProgramWithoutDepends(programConfigs []*Entry) {
 	dependsOnPrograms := p.getDependsOnInfo()
 	for _, config := range programConfigs {
 		if config.IsProgram() {
-			if _, ok := dependsOnPrograms[config.GetProgramName()]; !ok {
+			if _, ok := dependsOnPrograms[config.GetSectionProgramName()]; !ok {
 				p.procsWithooutDepends = append(p.procsWithooutDepends, config)
 			}
 		}
@@ -95,10 +95,21 @@ func (p *ProcessSorter) sortDepends() []string {
 	}
 
 	for len(finishedPrograms) < len(progsWithDependsInfo) {
+		progress := false
 		for progName := range p.dependsOnGraph {
 			if _, ok := finishedPrograms[progName]; !ok && p.inFinishedPrograms(progName, finishedPrograms) {
 				finishedPrograms[progName] = progName
 				progsStartOrder = append(progsStartOrder, progName)
+				progress = true
+			}
+		}
+		// the left programs depend on each other, it should be rejected by validateDependsOn
+		if !progress {
+			for progName := range p.dependsOnGraph {
+				if _, ok := finishedPrograms[progName]; !ok {
+					finishedPrograms[progName] = progName
+					progsStartOrder = append(progsStartOrder, progName)
+				}
 			}
 		}
 	}
@@ -145,7 +156,7 @@ func (p *ProcessSorter) SortProgram(programConfigs []*Entry) []*Entry {
 
 	for _, prog := range p.sortDepends() {
 		for _, config := range programConfigs {
-			if config.IsProgram() && config.GetProgramName() == prog {
+			if config.IsProgram() && config.GetSectionProgramName() == prog {
 				result = append(result, config)
 			}
 		}
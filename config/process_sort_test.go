@@ -0,0 +1,54 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSortProgram(t *testing.T) {
+	tests := []struct {
+		name string
+		conf string
+		// the programs in the start order, the programs in one item can be in any order
+		want []string
+	}{
+		{
+			name: "priority",
+			conf: "[program:a]\ncommand=/bin/true\npriority=2\n[program:b]\ncommand=/bin/true\npriority=1\n",
+			want: []string{"b", "a"},
+		},
+		{
+			name: "dependency",
+			conf: "[program:app]\ncommand=/bin/true\ndepends_on=db\n[program:db]\ncommand=/bin/true\n",
+			want: []string{"db", "app"},
+		},
+		{
+			name: "dependency with numprocs",
+			conf: "[program:app]\ncommand=/bin/true\ndepends_on=db\n" +
+				"[program:db]\ncommand=/bin/true\nnumprocs=2\nprocess_name=db_%(process_num)d\n",
+			want: []string{"db_1 db_2", "app"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loaded, diagnostics := loadTestConfig(t, test.conf)
+			if err := diagnosticsError(diagnostics); err != nil {
+				t.Fatal(err)
+			}
+			programs := loaded.GetPrograms()
+			i := 0
+			for _, names := range test.want {
+				expected := strings.Fields(names)
+				if i+len(expected) > len(programs) {
+					t.Fatalf("%d programs, want %v", len(programs), test.want)
+				}
+				for _, program := range programs[i : i+len(expected)] {
+					if !strings.Contains(" "+names+" ", " "+program.GetProgramName()+" ") {
+						t.Errorf("program %s at %d, want one of %s", program.GetProgramName(), i, names)
+					}
+				}
+				i += len(expected)
+			}
+		})
+	}
+}
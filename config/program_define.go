@@ -0,0 +1,168 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ochinchina/go-ini"
+	log "github.com/sirupsen/logrus"
+)
+
+// get the programs_dir in [supervisord] section, the programs added at runtime with persist are
+// saved to it and its *.conf files are loaded like the included files
+func (c *Config) getProgramsDir(cfg *ini.Ini) string {
+	section, err := cfg.GetSection("supervisord")
+	if err != nil {
+		return ""
+	}
+	dir, err := section.GetValue("programs_dir")
+	if err != nil {
+		return ""
+	}
+	dir, err = NewStringExpression("here", c.GetConfigFileDir()).Eval(dir)
+	if err != nil {
+		return ""
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(c.GetConfigFileDir(), dir)
+	}
+	return dir
+}
+
+// get the configuration files of the programs saved in the programs_dir
+func getProgramsDirFiles(dir string) []string {
+	if dir == "" {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.conf"))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(files)
+	return files
+}
+
+// the file to save the program in the programs_dir
+func (c *Config) programFile(programName string) string {
+	return filepath.Join(c.programsDir, programName+".conf")
+}
+
+// escape the value so it is read back by the ini loader as it is
+func escapeIniValue(value string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r", "\t", "\\t", ";", "\\;", "#", "\\#")
+	return replacer.Replace(value)
+}
+
+// AddProgramSection adds the program defined by the key/values of a [program:x] section, the
+// settings in [program-default] section are applied like the programs in the configuration file.
+// If persist is true, the program is saved to the programs_dir and loaded again on reload.
+//
+// Returns the process names of the instances of the program
+func (c *Config) AddProgramSection(programName string, keyValues map[string]string, persist bool) ([]string, error) {
+	if programName == "" || strings.ContainsAny(programName, ":[]/\\") {
+		return nil, fmt.Errorf("bad program name %q", programName)
+	}
+	if _, ok := c.programTemplates[programName]; ok {
+		return nil, fmt.Errorf("program %s already exists", programName)
+	}
+	if strings.TrimSpace(keyValues["command"]) == "" && !hasIndexedKeys("command", keyValues) {
+		return nil, fmt.Errorf("command of program %s is missing", programName)
+	}
+	if persist && c.programsDir == "" {
+		return nil, fmt.Errorf("programs_dir is not set in [supervisord] section")
+	}
+
+	keys := make([]string, 0, len(keyValues))
+	for key := range keyValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	cfg := ini.NewIni()
+	section := cfg.NewSection("program:" + programName)
+	for _, key := range keys {
+		section.Add(key, keyValues[key])
+	}
+	if programDefault, ok := c.entries["program-default"]; ok {
+		for key, value := range programDefault.keyValues {
+			if !section.HasKey(key) {
+				section.Add(key, value)
+			}
+		}
+	}
+
+	// the keys of the program are checked strictly, the keys inherited from [program-default] are
+	// already checked when the configuration is loaded
+	if err := diagnosticsError(c.validateSection(section, nil, newIniLocations(), c.ProgramGroup, keyValues)); err != nil {
+		return nil, err
+	}
+
+	// parse the program in a new configuration to check it before changing this one
+	loaded := NewConfig(c.configFile)
+	loaded.ProgramGroup = c.ProgramGroup.Clone()
+	procNames := loaded.parseProgram(cfg)
+	t, ok := loaded.programTemplates[programName]
+	if !ok || len(procNames) != t.numProcs {
+		return nil, fmt.Errorf("fail to parse program %s", programName)
+	}
+	if t.numProcs > 1 && !strings.Contains(t.processName, "%(process_num)") {
+		return nil, fmt.Errorf("process_name of program %s must contain %%(process_num) to run more than one instance", programName)
+	}
+	for _, procName := range procNames {
+		if _, ok := c.entries[procName]; ok {
+			return nil, fmt.Errorf("process %s already exists", procName)
+		}
+	}
+	if err := diagnosticsError(validateDependsOn(append(c.GetPrograms(), loaded.GetPrograms()...), newIniLocations())); err != nil {
+		return nil, err
+	}
+
+	if persist {
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "[program:%s]\n", programName)
+		for _, key := range keys {
+			fmt.Fprintf(&buf, "%s=%s\n", key, escapeIniValue(keyValues[key]))
+		}
+		if err := os.MkdirAll(c.programsDir, 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(c.programFile(programName), buf.Bytes(), 0o644); err != nil {
+			return nil, err
+		}
+	}
+	for _, procName := range procNames {
+		c.entries[procName] = loaded.entries[procName]
+	}
+	c.ProgramGroup.Add(loaded.ProgramGroup.GetGroup(programName, programName), programName)
+	c.programTemplates[programName] = t
+	return procNames, nil
+}
+
+// RemoveProgramSection removes the program and all its instances, the program saved in the
+// programs_dir is deleted. Returns the process names of the removed instances
+func (c *Config) RemoveProgramSection(programName string) ([]string, error) {
+	if _, ok := c.GetNumprocs(programName); !ok {
+		return nil, fmt.Errorf("no program named %s", programName)
+	}
+	procNames := c.GetProgramInstanceNames(programName)
+	for _, procName := range procNames {
+		c.RemoveProgram(procName)
+	}
+	c.ProgramGroup.Remove(programName)
+	delete(c.programTemplates, programName)
+	if _, ok := c.numprocsOverrides[programName]; ok {
+		delete(c.numprocsOverrides, programName)
+		if err := c.saveNumprocsOverrides(); err != nil {
+			log.WithFields(log.Fields{"program": programName, log.ErrorKey: err}).Error("fail to save numprocs")
+		}
+	}
+	if c.programsDir != "" {
+		if err := os.Remove(c.programFile(programName)); err != nil && !os.IsNotExist(err) {
+			log.WithFields(log.Fields{"program": programName, log.ErrorKey: err}).Error("fail to delete the program file")
+		}
+	}
+	return procNames, nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddProgramSection(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyValues map[string]string
+		// the expected error, empty if the program is added
+		err string
+	}{
+		{"valid", map[string]string{"command": "/bin/true", "autostart": "false"}, ""},
+		{"argv", map[string]string{"command[0]": "/bin/echo", "command[1]": "a, b"}, ""},
+		{"argv with a gap", map[string]string{"command[0]": "/bin/echo", "command[2]": "a"}, "command[1] is missing"},
+		{"missing command", map[string]string{"autostart": "false"}, "command of program p is missing"},
+		{"unknown key", map[string]string{"command": "/bin/true", "bogus_key": "1"}, "bogus_key: unknown key"},
+		{"misspelled key", map[string]string{"command": "/bin/true", "autostrat": "true"}, "did you mean autostart?"},
+		{"unsupported key", map[string]string{"command": "/bin/true", "umask": "022"}, "umask: key is not supported"},
+		{"bad value", map[string]string{"command": "/bin/true", "startsecs": "x"}, "startsecs: \"x\" is not an integer"},
+		{"unknown dependency", map[string]string{"command": "/bin/true", "depends_on": "db"}, "unknown program db"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the unsupported key in [program-default] is not rejected
+			loaded, _ := loadTestConfig(t, "[program-default]\numask=022\n")
+			cfg := NewConfig(loaded.configFile)
+			cfg.apply(loaded)
+			procNames, err := cfg.AddProgramSection("p", test.keyValues, false)
+			if test.err == "" {
+				if err != nil || len(procNames) != 1 {
+					t.Errorf("AddProgramSection() = %v, %v, want [p]", procNames, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("AddProgramSection() error = %v, want %s", err, test.err)
+			}
+			if cfg.GetProgram("p") != nil {
+				t.Errorf("the rejected program is added")
+			}
+		})
+	}
+}
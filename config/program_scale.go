@@ -0,0 +1,230 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ochinchina/go-ini"
+	log "github.com/sirupsen/logrus"
+)
+
+// programTemplate the section of a program to create its numprocs instances
+type programTemplate struct {
+	section     *ini.Section
+	prefix      string
+	programName string
+	// the command and process_name before %(process_num) is expanded
+	command     string
+	processName string
+	numProcs    int
+	// the numprocs in the configuration before it is scaled
+	configNumProcs int
+}
+
+// the name of the ith instance of the program
+func (c *Config) instanceName(t *programTemplate, i int) (string, error) {
+	return c.programExpression(t, i).Eval(t.processName)
+}
+
+// get the numprocs_file in [supervisord] section, the numprocs changed at runtime with persist
+// are saved to it and applied on the next start
+func (c *Config) getNumprocsFile(cfg *ini.Ini) string {
+	section, err := cfg.GetSection("supervisord")
+	if err != nil {
+		return ""
+	}
+	file, err := section.GetValue("numprocs_file")
+	if err != nil {
+		return ""
+	}
+	file, err = NewStringExpression("here", c.GetConfigFileDir()).Eval(file)
+	if err != nil {
+		return ""
+	}
+	return file
+}
+
+// load the persisted numprocs from the file, the ones changed at runtime take precedence
+func (c *Config) loadNumprocsOverrides(file string) map[string]int {
+	overrides := make(map[string]int)
+	if file != "" {
+		if content, err := os.ReadFile(file); err == nil {
+			scanner := bufio.NewScanner(bytes.NewReader(content))
+			for scanner.Scan() {
+				fields := strings.SplitN(strings.TrimSpace(scanner.Text()), "=", 2)
+				if len(fields) != 2 {
+					continue
+				}
+				if n, err := strconv.Atoi(strings.TrimSpace(fields[1])); err == nil && n >= 0 {
+					overrides[strings.TrimSpace(fields[0])] = n
+				}
+			}
+		} else if !os.IsNotExist(err) {
+			log.WithFields(log.Fields{"file": file, log.ErrorKey: err}).Error("fail to read numprocs file")
+		}
+	}
+	for name, n := range c.numprocsOverrides {
+		overrides[name] = n
+	}
+	return overrides
+}
+
+// save the persisted numprocs to the numprocs_file
+func (c *Config) saveNumprocsOverrides() error {
+	if c.numprocsFile == "" {
+		return nil
+	}
+	names := make([]string, 0, len(c.numprocsOverrides))
+	for name := range c.numprocsOverrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s=%d\n", name, c.numprocsOverrides[name])
+	}
+	return os.WriteFile(c.numprocsFile, buf.Bytes(), 0o644)
+}
+
+// GetNumprocs returns the number of the instances of the program, false if no such program
+func (c *Config) GetNumprocs(programName string) (int, bool) {
+	t, ok := c.programTemplates[programName]
+	if !ok || t.prefix != "program:" {
+		return 0, false
+	}
+	return t.numProcs, true
+}
+
+// GetProgramSectionNames returns the names of all the [program:x] sections
+func (c *Config) GetProgramSectionNames() []string {
+	names := make([]string, 0)
+	for name, t := range c.programTemplates {
+		if t.prefix == "program:" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetProgramSection returns the configuration of the [program:x] section without expanding
+// %(process_num), nil if no such program
+func (c *Config) GetProgramSection(programName string) *Entry {
+	t, ok := c.programTemplates[programName]
+	if !ok || t.prefix != "program:" {
+		return nil
+	}
+	entry := NewEntry(c.GetConfigFileDir())
+	entry.parse(t.section)
+	entry.Name = t.prefix + programName
+	entry.Group = c.ProgramGroup.GetGroup(programName, programName)
+	return entry
+}
+
+// GetProgramInstanceNames returns the process names of the instances of the program
+func (c *Config) GetProgramInstanceNames(programName string) []string {
+	names := make([]string, 0)
+	t, ok := c.programTemplates[programName]
+	if !ok {
+		return names
+	}
+	for i := 1; i <= t.numProcs; i++ {
+		if name, err := c.instanceName(t, i); err == nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// GetProgramOfProcess returns the name of the [program:x] section which creates the process
+func (c *Config) GetProgramOfProcess(procName string) (string, bool) {
+	for _, programName := range c.GetProgramSectionNames() {
+		for _, name := range c.GetProgramInstanceNames(programName) {
+			if name == procName {
+				return programName, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ScaleProgram changes the number of the instances of the program. The new instances are created
+// with the next process_num, the instances with the highest process_num are removed. If persist
+// is true, the numprocs is kept across reloads and saved to the numprocs_file.
+//
+// Returns the process names of the added and removed instances
+func (c *Config) ScaleProgram(programName string, numProcs int, persist bool) ([]string, []string, error) {
+	t, ok := c.programTemplates[programName]
+	if !ok || t.prefix != "program:" {
+		return nil, nil, fmt.Errorf("no program named %s", programName)
+	}
+	if numProcs < 0 {
+		return nil, nil, fmt.Errorf("numprocs must not be negative")
+	}
+	if numProcs > 1 && !strings.Contains(t.processName, "%(process_num)") {
+		return nil, nil, fmt.Errorf("process_name of program %s must contain %%(process_num) to run more than one instance", programName)
+	}
+
+	// the numprocs set by the user replaces the one set by the autoscaler
+	delete(c.autoscaledNumprocs, programName)
+	added := make([]string, 0)
+	removed := make([]string, 0)
+	oldNumProcs := t.numProcs
+	t.numProcs = numProcs
+	for i := oldNumProcs; i > numProcs; i-- {
+		name, err := c.instanceName(t, i)
+		if err != nil {
+			continue
+		}
+		c.RemoveProgram(name)
+		removed = append(removed, name)
+	}
+	for i := 1; i <= numProcs && i <= oldNumProcs; i++ {
+		if name, err := c.instanceName(t, i); err == nil {
+			if entry, ok := c.entries[name]; ok {
+				entry.keyValues["numprocs"] = fmt.Sprintf("%d", numProcs)
+			}
+		}
+	}
+	for i := oldNumProcs + 1; i <= numProcs; i++ {
+		if name, err := c.addProgramInstance(t, i); err == nil {
+			added = append(added, name)
+		}
+	}
+
+	if persist {
+		c.numprocsOverrides[programName] = numProcs
+		if err := c.saveNumprocsOverrides(); err != nil {
+			return added, removed, fmt.Errorf("fail to save numprocs to %s: %v", c.numprocsFile, err)
+		}
+	}
+	return added, removed, nil
+}
+
+// SetAutoscaledNumprocs keeps the numprocs of the program set by its autoscaler across reloads, it
+// is not persisted and only used while the program has autoscale_max
+func (c *Config) SetAutoscaledNumprocs(programName string, numProcs int) {
+	c.autoscaledNumprocs[programName] = numProcs
+}
+
+// get the numprocs set by the autoscaler of the program limited to autoscale_min and
+// autoscale_max, false if the program is not autoscaled or it is not scaled yet
+func (c *Config) getAutoscaledNumprocs(section *ini.Section) (int, bool) {
+	programName := section.Name[strings.Index(section.Name, ":")+1:]
+	numProcs, ok := c.autoscaledNumprocs[programName]
+	upper, err := section.GetInt("autoscale_max")
+	if !ok || err != nil || upper <= 0 {
+		delete(c.autoscaledNumprocs, programName)
+		return 0, false
+	}
+	lower, err := section.GetInt("autoscale_min")
+	if err != nil {
+		lower = 1
+	}
+	return max(lower, min(upper, numProcs)), true
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestAutoscaledNumprocsOnReload(t *testing.T) {
+	tests := []struct {
+		name       string
+		autoscaled int
+		conf       string
+		want       int
+	}{
+		{"kept", 3, "autoscale_max=5\n", 3},
+		{"limited by autoscale_max", 6, "autoscale_max=5\n", 5},
+		{"limited by autoscale_min", 1, "autoscale_max=5\nautoscale_min=2\n", 2},
+		{"not autoscaled any more", 3, "", 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loaded, diagnostics := loadTestConfig(t, "[program:w]\ncommand=/bin/true\nprocess_name=w_%(process_num)d\nautoscale_target=1\nautoscale_command=echo 1\nautoscale_max=9\n")
+			if err := diagnosticsError(diagnostics); err != nil {
+				t.Fatal(err)
+			}
+			cfg := NewConfig(loaded.configFile)
+			cfg.apply(loaded)
+			if _, _, err := cfg.ScaleProgram("w", test.autoscaled, false); err != nil {
+				t.Fatal(err)
+			}
+			cfg.SetAutoscaledNumprocs("w", test.autoscaled)
+
+			conf := "[program:w]\ncommand=/bin/true\nprocess_name=w_%(process_num)d\nautoscale_target=1\nautoscale_command=echo 1\n" + test.conf
+			if err := os.WriteFile(cfg.configFile, []byte(conf), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := cfg.Load(); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := cfg.GetNumprocs("w"); got != test.want {
+				t.Errorf("numprocs after reload = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
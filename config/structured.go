@@ -0,0 +1,511 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ochinchina/go-ini"
+	"gopkg.in/yaml.v3"
+)
+
+// A YAML or TOML configuration file has the same sections as the ini file. The sections with a
+// fixed name are the top level tables, the programs, event listeners and groups are the tables in
+// programs, eventlisteners and groups by their names:
+//
+//	supervisord:
+//	  logfile: /var/log/supervisord.log
+//	programs:
+//	  web:
+//	    command: /usr/bin/web
+//	    numprocs: 2
+//	    process_name: web_%(process_num)d
+//	groups:
+//	  frontend:
+//	    programs: [web]
+
+// the top level tables of the sections with a fixed name
+var fixedSections = []string{"supervisord", "unix_http_server", "inet_http_server", "supervisorctl", "include", "program-default"}
+
+// the top level tables of the sections with a name and the prefixes of their ini sections
+var namedSections = map[string]string{
+	"programs":       "program:",
+	"eventlisteners": "eventlistener:",
+	"groups":         "group:",
+}
+
+// the separators to join a list into the ini value, the other lists are joined with ','
+var listSeparators = map[string]string{
+	"files":                              " ",
+	"restart_signal_when_binary_changed": " ",
+	"restart_signal_when_file_changed":   " ",
+	"stopsignal":                         " ",
+}
+
+// isStructuredFile checks if the file is a YAML or TOML configuration file by its extension
+func isStructuredFile(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml", ".toml":
+		return true
+	}
+	return false
+}
+
+func isTOMLFile(file string) bool {
+	return strings.ToLower(filepath.Ext(file)) == ".toml"
+}
+
+// get the ini section and key of the path of a value in the structured file, the key is empty if
+// the path is a section. False if the path is not a section or a key of a section
+func toIniKey(path []string) (string, string, bool) {
+	if len(path) == 0 {
+		return "", "", false
+	}
+	if prefix, ok := namedSections[path[0]]; ok {
+		switch len(path) {
+		case 2:
+			return prefix + path[1], "", true
+		case 3:
+			return prefix + path[1], path[2], true
+		}
+		return "", "", false
+	}
+	for _, name := range fixedSections {
+		if name == path[0] {
+			switch len(path) {
+			case 1:
+				return name, "", true
+			case 2:
+				return name, path[1], true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// locate the section or the key at the path of the structured file
+func (l *iniLocations) locatePath(file string, path []string, line int) {
+	section, key, ok := toIniKey(path)
+	if !ok {
+		return
+	}
+	if key == "" {
+		l.sections[section] = location{file, line}
+	} else {
+		l.keys[section+"\x00"+key] = location{file, line}
+	}
+}
+
+// locate the keys of the YAML mapping node and its children
+func (l *iniLocations) locateYAML(file string, node *yaml.Node, path []string) {
+	if node.Kind == yaml.DocumentNode {
+		for _, child := range node.Content {
+			l.locateYAML(file, child, path)
+		}
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		childPath := append(append([]string{}, path...), node.Content[i].Value)
+		l.locatePath(file, childPath, node.Content[i].Line)
+		l.locateYAML(file, node.Content[i+1], childPath)
+	}
+}
+
+// the key of a key/value line in TOML, e.g. "command" in `command = "/bin/cat"`
+var tomlKeyRegexp = regexp.MustCompile(`^\s*((?:"[^"]*"|'[^']*'|[A-Za-z0-9_.\-\s])+?)\s*=`)
+
+// the line and the message of a YAML syntax error, e.g. "yaml: line 3: did not find expected key"
+var yamlErrorRegexp = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// split the dotted TOML key into its parts, the quoted parts are unquoted
+func splitTOMLKey(key string) []string {
+	parts := make([]string, 0)
+	var part strings.Builder
+	quote := byte(0)
+	for i := 0; i < len(key); i++ {
+		ch := key[i]
+		switch {
+		case quote != 0 && ch == quote:
+			quote = 0
+		case quote != 0:
+			part.WriteByte(ch)
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '.':
+			parts = append(parts, strings.TrimSpace(part.String()))
+			part.Reset()
+		default:
+			part.WriteByte(ch)
+		}
+	}
+	return append(parts, strings.TrimSpace(part.String()))
+}
+
+// locate the tables and the keys in the TOML content line by line
+func (l *iniLocations) locateTOML(file string, content []byte) {
+	table := make([]string, 0)
+	multiline := ""
+	for i, line := range strings.Split(string(content), "\n") {
+		if multiline != "" {
+			if strings.Contains(line, multiline) {
+				multiline = ""
+			}
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			if end := strings.LastIndex(trimmed, "]"); end != -1 {
+				table = splitTOMLKey(strings.Trim(trimmed[:end+1], "[]"))
+				l.locatePath(file, table, i+1)
+			}
+			continue
+		}
+		if m := tomlKeyRegexp.FindStringSubmatch(line); m != nil {
+			l.locatePath(file, append(append([]string{}, table...), splitTOMLKey(m[1])...), i+1)
+			value := line[len(m[0]):]
+			for _, delimiter := range []string{`"""`, `'''`} {
+				if strings.Count(value, delimiter)%2 == 1 {
+					multiline = delimiter
+				}
+			}
+		}
+	}
+}
+
+// convert a scalar or a list of the structured file to the ini value
+func toIniValue(key string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if _, ok := item.([]interface{}); ok {
+				return "", fmt.Errorf("a list in a list is not supported")
+			}
+			s, err := toIniValue(key, item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		sep, ok := listSeparators[key]
+		if !ok {
+			sep = ","
+		}
+		return strings.Join(items, sep), nil
+	case map[string]interface{}:
+		return "", fmt.Errorf("a table is only supported by environment of the programs")
+	}
+	return "", fmt.Errorf("unsupported value %v", value)
+}
+
+// convert the argv list of the command or the environment table of a program to the indexed keys
+// like command[0] and environment[A], nil if the value is not declared by the indexed keys
+func toIndexedValues(sectionName string, key string, value interface{}) (map[string]string, error) {
+	if !isIndexedKey(sectionName, key) {
+		return nil, nil
+	}
+	result := make(map[string]string)
+	if items, ok := value.([]interface{}); ok && indexedKeys[key] {
+		if len(items) == 0 {
+			return nil, fmt.Errorf("the argv list is empty")
+		}
+		for i, item := range items {
+			if _, ok := item.([]interface{}); ok {
+				return nil, fmt.Errorf("a list in the argv list is not supported")
+			}
+			s, err := toIniValue(key, item)
+			if err != nil {
+				return nil, err
+			}
+			result[fmt.Sprintf("%s[%d]", key, i)] = s
+		}
+		return result, nil
+	}
+	if table, ok := value.(map[string]interface{}); ok && !indexedKeys[key] {
+		for name, item := range table {
+			if err := checkIndex(key, name); err != nil {
+				return nil, err
+			}
+			s, err := toIniValue(name, item)
+			if err != nil {
+				return nil, err
+			}
+			result[fmt.Sprintf("%s[%s]", key, name)] = s
+		}
+		return result, nil
+	}
+	return nil, nil
+}
+
+// get the keys of the map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// add the keys of the table to the section of the ini
+func addStructuredSection(cfg *ini.Ini, locations *iniLocations, sectionName string, table interface{}) []Diagnostic {
+	keyValues, ok := table.(map[string]interface{})
+	if !ok {
+		return []Diagnostic{locations.newDiagnostic(sectionName, "", SeverityError, "section must be a table")}
+	}
+	diagnostics := make([]Diagnostic, 0)
+	section := cfg.NewSection(sectionName)
+	keys := make([]string, 0, len(keyValues))
+	for key := range keyValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if indexed, err := toIndexedValues(sectionName, key, keyValues[key]); err != nil {
+			diagnostics = append(diagnostics, locations.newDiagnostic(sectionName, key, SeverityError, "%v", err))
+			continue
+		} else if indexed != nil {
+			loc, ok := locations.keys[sectionName+"\x00"+key]
+			for _, name := range sortedKeys(indexed) {
+				section.Add(name, indexed[name])
+				if ok {
+					locations.keys[sectionName+"\x00"+name] = loc
+				}
+			}
+			continue
+		}
+		value, err := toIniValue(key, keyValues[key])
+		if err != nil {
+			diagnostics = append(diagnostics, locations.newDiagnostic(sectionName, key, SeverityError, "%v", err))
+			continue
+		}
+		section.Add(key, value)
+	}
+	return diagnostics
+}
+
+// decode the YAML or TOML content to the tables and locate its keys
+func decodeStructured(file string, content []byte, locations *iniLocations) (map[string]interface{}, *Diagnostic) {
+	doc := make(map[string]interface{})
+	if isTOMLFile(file) {
+		if _, err := toml.Decode(string(content), &doc); err != nil {
+			d := Diagnostic{File: file, Severity: SeverityError, Message: err.Error()}
+			var parseErr toml.ParseError
+			if errors.As(err, &parseErr) {
+				d.Line, d.Message = parseErr.Position.Line, parseErr.Message
+			}
+			return nil, &d
+		}
+		locations.locateTOML(file, content)
+		return doc, nil
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		d := Diagnostic{File: file, Severity: SeverityError, Message: err.Error()}
+		if m := yamlErrorRegexp.FindStringSubmatch(d.Message); m != nil {
+			d.Line, _ = strconv.Atoi(m[1])
+			d.Message = m[2]
+		}
+		return nil, &d
+	}
+	if err := node.Decode(&doc); err != nil {
+		return nil, &Diagnostic{File: file, Severity: SeverityError, Message: err.Error()}
+	}
+	locations.locateYAML(file, &node, nil)
+	return doc, nil
+}
+
+// load the YAML or TOML file into the sections of the ini
+func loadStructuredFile(file string, cfg *ini.Ini, locations *iniLocations) []Diagnostic {
+	locations.files = append(locations.files, file)
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return []Diagnostic{{File: file, Severity: SeverityError, Message: fmt.Sprintf("fail to read the file: %v", err)}}
+	}
+	doc, d := decodeStructured(file, content, locations)
+	if d != nil {
+		return []Diagnostic{*d}
+	}
+
+	diagnostics := make([]Diagnostic, 0)
+	names := make([]string, 0, len(doc))
+	for name := range doc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if prefix, ok := namedSections[name]; ok {
+			tables, ok := doc[name].(map[string]interface{})
+			if !ok {
+				diagnostics = append(diagnostics, Diagnostic{File: file, Section: name, Severity: SeverityError,
+					Message: fmt.Sprintf("%s must be a table of the %s sections by their names", name, strings.TrimSuffix(prefix, ":"))})
+				continue
+			}
+			for tableName, table := range tables {
+				diagnostics = append(diagnostics, addStructuredSection(cfg, locations, prefix+tableName, table)...)
+			}
+		} else if _, _, ok := toIniKey([]string{name}); ok {
+			diagnostics = append(diagnostics, addStructuredSection(cfg, locations, name, doc[name])...)
+		} else {
+			diagnostics = append(diagnostics, Diagnostic{File: file, Section: name, Severity: SeverityWarning, Message: "unknown section is ignored"})
+		}
+	}
+	return diagnostics
+}
+
+// LoadIniFile loads the ini, YAML or TOML configuration file without its included files
+func LoadIniFile(file string) *ini.Ini {
+	cfg := ini.NewIni()
+	if isStructuredFile(file) {
+		loadStructuredFile(file, cfg, newIniLocations())
+	} else {
+		cfg.LoadFile(file)
+	}
+	return cfg
+}
+
+// convert the ini value to a YAML or TOML value, the integers and the booleans are not quoted
+func fromIniValue(value string) interface{} {
+	if value == "true" || value == "false" {
+		return value == "true"
+	}
+	// keep the leading zeros, e.g. umask=022
+	if i, err := strconv.Atoi(value); err == nil && strconv.Itoa(i) == value {
+		return i
+	}
+	return value
+}
+
+// convert the keys of the ini section to a YAML or TOML table, the indexed keys like command[0] and
+// environment[A] are converted to the argv list and the environment table
+func fromIniSection(section *ini.Section) map[string]interface{} {
+	keyValues := make(map[string]interface{})
+	rawKeyValues := sectionKeyValues(section)
+	indexed := make(map[string]bool)
+	for name, value := range rawKeyValues {
+		key, index, ok := splitIndexedKey(name)
+		if !ok || !isIndexedKey(section.Name, key) || checkIndex(key, index) != nil {
+			keyValues[name] = fromIniValue(strings.TrimSpace(value))
+		} else {
+			indexed[key] = true
+		}
+	}
+	for key := range indexed {
+		if indexedKeys[key] {
+			args := make([]interface{}, 0)
+			for _, arg := range getIndexedArray(key, rawKeyValues) {
+				args = append(args, arg)
+			}
+			keyValues[key] = args
+			continue
+		}
+		// the variables of the key and the indexed keys are merged like they are loaded
+		table := make(map[string]interface{})
+		for name, value := range getEnvironment(key, rawKeyValues) {
+			table[name] = value
+		}
+		keyValues[key] = table
+	}
+	return keyValues
+}
+
+// the document of a YAML or TOML configuration file, the fields are in the order they are written
+type structuredDocument struct {
+	Supervisord    map[string]interface{}            `yaml:"supervisord,omitempty" toml:"supervisord,omitempty"`
+	UnixHTTPServer map[string]interface{}            `yaml:"unix_http_server,omitempty" toml:"unix_http_server,omitempty"`
+	InetHTTPServer map[string]interface{}            `yaml:"inet_http_server,omitempty" toml:"inet_http_server,omitempty"`
+	Supervisorctl  map[string]interface{}            `yaml:"supervisorctl,omitempty" toml:"supervisorctl,omitempty"`
+	Include        map[string]interface{}            `yaml:"include,omitempty" toml:"include,omitempty"`
+	ProgramDefault map[string]interface{}            `yaml:"program-default,omitempty" toml:"program-default,omitempty"`
+	Programs       map[string]map[string]interface{} `yaml:"programs,omitempty" toml:"programs,omitempty"`
+	EventListeners map[string]map[string]interface{} `yaml:"eventlisteners,omitempty" toml:"eventlisteners,omitempty"`
+	Groups         map[string]map[string]interface{} `yaml:"groups,omitempty" toml:"groups,omitempty"`
+}
+
+// ConvertIniFile converts the ini configuration file to YAML or TOML, the included files are not
+// converted. The sections which can't be converted are returned also
+func ConvertIniFile(file string, format string) ([]byte, []string, error) {
+	if format != "yaml" && format != "toml" {
+		return nil, nil, fmt.Errorf("unknown format %s", format)
+	}
+	if _, err := os.Stat(file); err != nil {
+		return nil, nil, err
+	}
+	cfg := ini.NewIni()
+	cfg.LoadFile(file)
+
+	var doc structuredDocument
+	fixed := map[string]*map[string]interface{}{
+		"supervisord":      &doc.Supervisord,
+		"unix_http_server": &doc.UnixHTTPServer,
+		"inet_http_server": &doc.InetHTTPServer,
+		"supervisorctl":    &doc.Supervisorctl,
+		"include":          &doc.Include,
+		"program-default":  &doc.ProgramDefault,
+	}
+	named := map[string]*map[string]map[string]interface{}{
+		"program:":       &doc.Programs,
+		"eventlistener:": &doc.EventListeners,
+		"group:":         &doc.Groups,
+	}
+	skipped := make([]string, 0)
+	for _, section := range cfg.Sections() {
+		keyValues := fromIniSection(section)
+		if table, ok := fixed[section.Name]; ok {
+			*table = keyValues
+			continue
+		}
+		converted := false
+		for prefix, tables := range named {
+			if strings.HasPrefix(section.Name, prefix) {
+				if *tables == nil {
+					*tables = make(map[string]map[string]interface{})
+				}
+				(*tables)[section.Name[len(prefix):]] = keyValues
+				converted = true
+			}
+		}
+		if !converted {
+			skipped = append(skipped, section.Name)
+		}
+	}
+	sort.Strings(skipped)
+
+	var buf bytes.Buffer
+	if format == "toml" {
+		if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+			return nil, skipped, err
+		}
+		return buf.Bytes(), skipped, nil
+	}
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, skipped, err
+	}
+	return buf.Bytes(), skipped, nil
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// the error diagnostics as "line: message"
+func errorLines(diagnostics []Diagnostic) []string {
+	result := make([]string, 0)
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			result = append(result, fmt.Sprintf("%d: %s", d.Line, d.Message))
+		}
+	}
+	return result
+}
+
+func TestLoadStructuredFile(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		conf string
+		// the process names, the command and the environment of the first process
+		procs   []string
+		command string
+		env     []string
+	}{
+		{
+			name: "yaml",
+			file: "supervisord.yaml",
+			conf: "supervisord:\n  logfile: /tmp/sv.log\n" +
+				"programs:\n  web:\n    command: /bin/sleep 10\n    numprocs: 2\n    process_name: web_%(process_num)d\n" +
+				"    autostart: false\n    environment: A=1,B=\"x, y\"\n",
+			procs:   []string{"web_1", "web_2"},
+			command: "/bin/sleep 10",
+			env:     []string{"A=1", "B=x, y"},
+		},
+		{
+			name: "yaml argv and environment table",
+			file: "supervisord.yml",
+			conf: "supervisord: {}\n" +
+				"programs:\n  web:\n    command: [/bin/sh, -c, \"echo a, b\"]\n    environment:\n      A: x,\"y\"\n      B: 1\n",
+			procs:   []string{"web"},
+			command: `/bin/sh -c "echo a, b"`,
+			env:     []string{`A=x,"y"`, "B=1"},
+		},
+		{
+			name: "toml",
+			file: "supervisord.toml",
+			conf: "[supervisord]\nlogfile = \"/tmp/sv.log\"\n" +
+				"[programs.web]\ncommand = \"/bin/sleep 10\"\nnumprocs = 2\nprocess_name = \"web_%(process_num)d\"\n" +
+				"autostart = false\nenvironment = 'A=1,B=\"x, y\"'\n",
+			procs:   []string{"web_1", "web_2"},
+			command: "/bin/sleep 10",
+			env:     []string{"A=1", "B=x, y"},
+		},
+		{
+			name: "toml argv and environment table",
+			file: "supervisord.toml",
+			conf: "[supervisord]\n" +
+				"[programs.web]\ncommand = [\"/bin/sh\", \"-c\", \"echo a, b\"]\n[programs.web.environment]\nA = 'x,\"y\"'\nB = 1\n",
+			procs:   []string{"web"},
+			command: `/bin/sh -c "echo a, b"`,
+			env:     []string{`A=x,"y"`, "B=1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loaded, diagnostics := loadTestConfigFile(t, filepath.Join(t.TempDir(), test.file), test.conf)
+			if HasErrors(diagnostics) {
+				t.Fatal(diagnostics)
+			}
+			procs := make([]string, 0)
+			for _, entry := range loaded.GetPrograms() {
+				procs = append(procs, entry.GetProgramName())
+			}
+			sort.Strings(procs)
+			if strings.Join(procs, ",") != strings.Join(test.procs, ",") {
+				t.Fatalf("programs = %v, want %v", procs, test.procs)
+			}
+			entry := loaded.GetProgram(test.procs[0])
+			if got := entry.GetCommand(); got != test.command {
+				t.Errorf("command = %s, want %s", got, test.command)
+			}
+			env := entry.GetEnv("environment")
+			sort.Strings(env)
+			if strings.Join(env, "\n") != strings.Join(test.env, "\n") {
+				t.Errorf("environment = %q, want %q", env, test.env)
+			}
+		})
+	}
+}
+
+func TestStructuredDiagnostics(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		conf string
+		// the lines and the messages of the errors
+		want []string
+	}{
+		{
+			name: "yaml key",
+			file: "supervisord.yaml",
+			conf: "supervisord: {}\nprograms:\n  web:\n    command: /bin/true\n    startsecs: x\n",
+			want: []string{`5: "x" is not an integer`},
+		},
+		{
+			name: "yaml empty argv",
+			file: "supervisord.yaml",
+			conf: "supervisord: {}\nprograms:\n  web:\n    command: []\n",
+			want: []string{"4: the argv list is empty", "4: command is missing"},
+		},
+		{
+			name: "yaml syntax",
+			file: "supervisord.yaml",
+			conf: "supervisord: {}\nprograms:\n  web:\n    command: /bin/true\n    autostart: a: b\n",
+			want: []string{"5: mapping values are not allowed in this context"},
+		},
+		{
+			name: "toml key",
+			file: "supervisord.toml",
+			conf: "[supervisord]\n\n[programs.web]\ncommand = \"/bin/true\"\nstartsecs = \"x\"\n",
+			want: []string{`5: "x" is not an integer`},
+		},
+		{
+			name: "toml indexed key",
+			file: "supervisord.toml",
+			conf: "[supervisord]\n[programs.web]\ncommand = [\"/bin/sh\", \"-c\", \"%(bad\"]\n",
+			want: []string{`3: unparseable expression "%(bad": `},
+		},
+		{
+			name: "toml syntax",
+			file: "supervisord.toml",
+			conf: "[supervisord]\n[programs.web]\ncommand = \"/bin/true\n",
+			want: []string{"3: "},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, diagnostics := loadTestConfigFile(t, filepath.Join(t.TempDir(), test.file), test.conf)
+			got := errorLines(diagnostics)
+			if len(got) != len(test.want) {
+				t.Fatalf("errors = %q, want %q", got, test.want)
+			}
+			for i := range got {
+				if !strings.HasPrefix(got[i], test.want[i]) {
+					t.Errorf("error = %q, want %q", got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+// the settings of the entries which are kept by the conversion, the environment is compared by
+// its variables because it is converted to a table
+func describeEntries(cfg *Config) []string {
+	result := make([]string, 0)
+	for _, entry := range cfg.entries {
+		keys := make([]string, 0)
+		for key, value := range entry.keyValues {
+			if name, _, ok := splitIndexedKey(key); key != "environment" && (!ok || name != "environment") {
+				keys = append(keys, fmt.Sprintf("%s=%s", key, value))
+			}
+		}
+		sort.Strings(keys)
+		env := entry.GetEnv("environment")
+		sort.Strings(env)
+		result = append(result, fmt.Sprintf("%s group=%s %s env=%q", entry.Name, entry.Group, strings.Join(keys, " "), env))
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestConvertIniFile(t *testing.T) {
+	conf := "[supervisord]\nlogfile=/tmp/sv.log\nloglevel=info\n" +
+		"[inet_http_server]\nport=127.0.0.1:9001\n" +
+		"[program-default]\nstartsecs=0\n" +
+		"[program:web]\ncommand=/bin/sleep 10\nnumprocs=2\nprocess_name=web_%(process_num)d\nautostart=false\n" +
+		"environment=A=1,B=\"x, y\"\nenvironment[C]=x,\"y\"\nstopsignal=TERM INT\n" +
+		"[program:job]\ncommand[0]=/bin/sh\ncommand[1]=-c\ncommand[2]=echo a, b\ndepends_on=web\n" +
+		"[eventlistener:listener]\ncommand=/bin/cat\nevents=PROCESS_STATE\n" +
+		"[group:frontend]\nprograms=web\n"
+	dir := t.TempDir()
+	iniFile := filepath.Join(dir, "supervisord.conf")
+	loaded, diagnostics := loadTestConfigFile(t, iniFile, conf)
+	if HasErrors(diagnostics) {
+		t.Fatal(diagnostics)
+	}
+	want := describeEntries(loaded)
+
+	for _, format := range []string{"yaml", "toml"} {
+		t.Run(format, func(t *testing.T) {
+			content, skipped, err := ConvertIniFile(iniFile, format)
+			if err != nil || len(skipped) != 0 {
+				t.Fatalf("ConvertIniFile() = %v, %v", skipped, err)
+			}
+			// the converted file is in the same directory so %(here)s is not changed
+			converted, diagnostics := loadTestConfigFile(t, filepath.Join(dir, "supervisord."+format), string(content))
+			if HasErrors(diagnostics) {
+				t.Fatalf("%v\n%s", diagnostics, content)
+			}
+			got := describeEntries(converted)
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("converted entries:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestConvertIniFileErrors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "supervisord.conf")
+	if err := os.WriteFile(file, []byte("[supervisord]\n[unknown]\na=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ConvertIniFile(file, "json"); err == nil {
+		t.Errorf("ConvertIniFile() with an unknown format, want an error")
+	}
+	if _, skipped, err := ConvertIniFile(file, "yaml"); err != nil || strings.Join(skipped, ",") != "unknown" {
+		t.Errorf("ConvertIniFile() = %v, %v, want the unknown section skipped", skipped, err)
+	}
+}
@@ -0,0 +1,642 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ochinchina/go-ini"
+	"github.com/ochinchina/supervisord/signals"
+	log "github.com/sirupsen/logrus"
+)
+
+// Severity the severity of a problem found in the configuration
+type Severity string
+
+const (
+	// SeverityError the configuration can't be used
+	SeverityError Severity = "error"
+	// SeverityWarning the configuration can be used but some settings are ignored
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic a problem found in the configuration and where it is
+type Diagnostic struct {
+	File     string
+	Line     int // 0 if the problem is not about a line
+	Section  string
+	Key      string
+	Severity Severity
+	Message  string
+}
+
+// String formats the diagnostic as "file:line: severity: [section] key: message"
+func (d Diagnostic) String() string {
+	var buf strings.Builder
+	if d.File != "" {
+		buf.WriteString(d.File)
+		if d.Line > 0 {
+			fmt.Fprintf(&buf, ":%d", d.Line)
+		}
+		buf.WriteString(": ")
+	}
+	fmt.Fprintf(&buf, "%s: ", d.Severity)
+	if d.Section != "" {
+		fmt.Fprintf(&buf, "[%s] ", d.Section)
+	}
+	if d.Key != "" {
+		fmt.Fprintf(&buf, "%s: ", d.Key)
+	}
+	buf.WriteString(d.Message)
+	return buf.String()
+}
+
+// HasErrors checks if any of the diagnostics is an error
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// the error of the configuration with all the error diagnostics, nil if there is no error
+func diagnosticsError(diagnostics []Diagnostic) error {
+	errs := make([]string, 0)
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			errs = append(errs, d.String())
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n%s", strings.Join(errs, "\n"))
+}
+
+func logDiagnostics(diagnostics []Diagnostic) {
+	for _, d := range diagnostics {
+		entry := log.WithFields(log.Fields{"file": d.File, "line": d.Line, "section": d.Section, "key": d.Key})
+		if d.Severity == SeverityError {
+			entry.Error(d.Message)
+		} else {
+			entry.Warn(d.Message)
+		}
+	}
+}
+
+// SortDiagnostics sorts the diagnostics in the order the files are loaded and by the lines
+func (c *Config) SortDiagnostics(diagnostics []Diagnostic) {
+	order := make(map[string]int)
+	for i, file := range c.locations.files {
+		order[file] = i + 1
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].File != diagnostics[j].File {
+			return order[diagnostics[i].File] < order[diagnostics[j].File]
+		}
+		return diagnostics[i].Line < diagnostics[j].Line
+	})
+}
+
+// the file and the line where a section or a key is defined
+type location struct {
+	file string
+	line int
+}
+
+// iniLocations the locations of the sections and the keys in the loaded files, a later definition
+// overrides the earlier one like the ini loader does
+type iniLocations struct {
+	files    []string // in the order they are loaded
+	sections map[string]location
+	keys     map[string]location
+}
+
+func newIniLocations() *iniLocations {
+	return &iniLocations{sections: make(map[string]location), keys: make(map[string]location)}
+}
+
+// get the location of the key in the section, or the section itself if the key is empty
+func (l *iniLocations) find(section string, key string) location {
+	if loc, ok := l.keys[section+"\x00"+key]; ok && key != "" {
+		return loc
+	}
+	return l.sections[section]
+}
+
+// create a diagnostic located at the key in the section
+func (l *iniLocations) newDiagnostic(section string, key string, severity Severity, format string, args ...interface{}) Diagnostic {
+	loc := l.find(section, key)
+	return Diagnostic{File: loc.file, Line: loc.line, Section: section, Key: key, Severity: severity, Message: fmt.Sprintf(format, args...)}
+}
+
+// NewDiagnostic creates a diagnostic located at the key of the section in the loaded files
+func (c *Config) NewDiagnostic(section string, key string, severity Severity, format string, args ...interface{}) Diagnostic {
+	return c.locations.newDiagnostic(section, key, severity, format, args...)
+}
+
+// return the number of spaces before non-space chars
+func getIndent(s string) int {
+	return len(s) - len(strings.TrimLeftFunc(s, unicode.IsSpace))
+}
+
+// check if the value is continued in the next line, e.g. it ends with an odd number of '\'
+func hasContinuation(value string) bool {
+	n := len(value) - len(strings.TrimRight(value, "\\"))
+	return n%2 == 1
+}
+
+// scan the file to locate its sections and keys, the lines are recognized like the ini loader
+func (l *iniLocations) scanFile(file string) []Diagnostic {
+	l.files = append(l.files, file)
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return []Diagnostic{{File: file, Severity: SeverityError, Message: fmt.Sprintf("fail to read the file: %v", err)}}
+	}
+	diagnostics := make([]Diagnostic, 0)
+	section := ""
+	keyIndent := -1
+	multiline := false
+	continuation := false
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if multiline {
+			multiline = !strings.HasSuffix(strings.TrimRightFunc(line, unicode.IsSpace), `"""`)
+			continue
+		}
+		if continuation {
+			continuation = hasContinuation(strings.TrimRightFunc(line, unicode.IsSpace))
+			continue
+		}
+		// the value of the previous key in an indented line
+		if keyIndent >= 0 && getIndent(line) > keyIndent {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == ';' || trimmed[0] == '#' {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			keyIndent = -1
+			l.sections[section] = location{file, i + 1}
+			continue
+		}
+		pos := strings.IndexAny(line, "=:")
+		if pos == -1 {
+			diagnostics = append(diagnostics, Diagnostic{File: file, Line: i + 1, Section: section, Severity: SeverityWarning,
+				Message: fmt.Sprintf("line %q is not a section or a key=value and is ignored", trimmed)})
+			continue
+		}
+		keyIndent = getIndent(line)
+		key := strings.TrimSpace(line[:pos])
+		value := strings.TrimSpace(line[pos+1:])
+		if strings.HasPrefix(value, `"""`) {
+			multiline = len(value) < 6 || !strings.HasSuffix(value, `"""`)
+		} else {
+			continuation = hasContinuation(value)
+		}
+		if key == "" {
+			continue
+		}
+		// the keys before the first section are put in the default section by the loader
+		if section == "" {
+			section = "default"
+			l.sections[section] = location{file, i + 1}
+		}
+		l.keys[section+"\x00"+key] = location{file, i + 1}
+	}
+	return diagnostics
+}
+
+// the expressions to check the values of a section
+type checkContext struct {
+	here    *StringExpression // for the values read with GetString
+	program *StringExpression // for the values read with GetStringExpression
+}
+
+// keyChecker checks the value of a key, a nil keyChecker means the key is accepted for
+// compatibility with supervisor but is not supported
+type keyChecker func(value string, ctx *checkContext) error
+
+func checkString(value string, ctx *checkContext) error {
+	if _, err := ctx.here.Eval(value); err != nil {
+		return fmt.Errorf("unparseable expression %q: %v", value, err)
+	}
+	return nil
+}
+
+func checkExpression(value string, ctx *checkContext) error {
+	if _, err := ctx.program.Eval(value); err != nil {
+		return fmt.Errorf("unparseable expression %q: %v", value, err)
+	}
+	return nil
+}
+
+func checkEnvironment(value string, ctx *checkContext) error {
+	if _, err := parseEnvVariables(value); err != nil {
+		return err
+	}
+	return checkExpression(value, ctx)
+}
+
+func checkInt(value string, _ *checkContext) error {
+	if _, err := strconv.Atoi(value); err != nil {
+		return fmt.Errorf("%q is not an integer", value)
+	}
+	return nil
+}
+
+func checkFloat(value string, _ *checkContext) error {
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return fmt.Errorf("%q is not a number", value)
+	}
+	return nil
+}
+
+func checkBool(value string, _ *checkContext) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("%q is not a boolean, use true or false", value)
+	}
+	return nil
+}
+
+// check the bytes like GetBytes, e.g. 1024, 1KB, 1MB or 1GB
+func checkBytes(value string, _ *checkContext) error {
+	number := value
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if len(value) > 2 && strings.HasSuffix(value, suffix) {
+			number = value[:len(value)-2]
+			break
+		}
+	}
+	if _, err := strconv.Atoi(number); err != nil {
+		return fmt.Errorf("%q is not a size, use a number with an optional KB, MB or GB suffix", value)
+	}
+	return nil
+}
+
+// check the signals separated by spaces, e.g. "TERM KILL"
+func checkSignals(value string, _ *checkContext) error {
+	for _, name := range strings.Fields(value) {
+		if _, err := signals.ParseSignal(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check the integers separated by ',', e.g. "0,2"
+func checkExitCodes(value string, _ *checkContext) error {
+	for _, code := range strings.Split(value, ",") {
+		if _, err := strconv.Atoi(strings.TrimSpace(code)); err != nil {
+			return fmt.Errorf("%q is not an integer", strings.TrimSpace(code))
+		}
+	}
+	return nil
+}
+
+// create a checker accepting one of the choices ignoring the case
+func checkChoice(choices ...string) keyChecker {
+	return func(value string, _ *checkContext) error {
+		for _, choice := range choices {
+			if strings.EqualFold(value, choice) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %s", value, strings.Join(choices, ", "))
+	}
+}
+
+// the keys of [program:x], [eventlistener:x] and [program-default] sections
+var programKeys = map[string]keyChecker{
+	"autorestart":                        checkChoice("true", "false", "unexpected"),
+	"autoscale_command":                  checkString,
+	"autoscale_cpu":                      checkBool,
+	"autoscale_http":                     checkString,
+	"autoscale_interval":                 checkInt,
+	"autoscale_max":                      checkInt,
+	"autoscale_min":                      checkInt,
+	"autoscale_scale_down_cooldown":      checkInt,
+	"autoscale_scale_up_cooldown":        checkInt,
+	"autoscale_target":                   checkFloat,
+	"autostart":                          checkBool,
+	"cascade_restart":                    checkBool,
+	"command":                            checkExpression,
+	"conf_file":                          checkString,
+	"cron":                               checkString,
+	"depends_on":                         checkString,
+	"directory":                          checkExpression,
+	"envFiles":                           checkString,
+	"environment":                        checkEnvironment,
+	"exitcodes":                          checkExitCodes,
+	"healthcheck_command":                checkExpression,
+	"healthcheck_http":                   checkExpression,
+	"healthcheck_http_status":            checkInt,
+	"healthcheck_interval":               checkInt,
+	"healthcheck_restart":                checkBool,
+	"healthcheck_retries":                checkInt,
+	"healthcheck_start_period":           checkInt,
+	"healthcheck_tcp":                    checkExpression,
+	"healthcheck_timeout":                checkInt,
+	"killasgroup":                        checkBool,
+	"killwaitsecs":                       checkInt,
+	"numprocs":                           checkInt,
+	"numprocs_start":                     nil,
+	"priority":                           checkInt,
+	"process_name":                       checkExpression,
+	"ready_file":                         checkExpression,
+	"ready_log_regex":                    checkString,
+	"ready_notify":                       checkBool,
+	"ready_timeout":                      checkInt,
+	"redirect_stderr":                    checkBool,
+	"restart_backoff_initial":            checkFloat,
+	"restart_backoff_jitter":             checkFloat,
+	"restart_backoff_max":                checkFloat,
+	"restart_backoff_multiplier":         checkFloat,
+	"restart_backoff_reset":              checkFloat,
+	"restart_cmd_when_binary_changed":    checkString,
+	"restart_cmd_when_file_changed":      checkString,
+	"restart_directory_monitor":          checkString,
+	"restart_file_pattern":               checkString,
+	"restart_limit":                      checkInt,
+	"restart_limit_action":               checkChoice("none", "stop_group", "shutdown"),
+	"restart_limit_window":               checkInt,
+	"restart_signal_when_binary_changed": checkSignals,
+	"restart_signal_when_file_changed":   checkSignals,
+	"restart_when_binary_changed":        checkBool,
+	"restartpause":                       checkInt,
+	"rolling_restart_batch":              checkInt,
+	"rolling_restart_timeout":            checkInt,
+	"serverurl":                          nil,
+	"startretries":                       checkInt,
+	"startsecs":                          checkInt,
+	"stderr_capture_maxbytes":            checkBytes,
+	"stderr_events_enabled":              checkBool,
+	"stderr_logfile":                     checkExpression,
+	"stderr_logfile_backups":             checkInt,
+	"stderr_logfile_maxbytes":            checkBytes,
+	"stderr_syslog":                      nil,
+	"stdout_capture_maxbytes":            checkBytes,
+	"stdout_events_enabled":              checkBool,
+	"stdout_logfile":                     checkExpression,
+	"stdout_logfile_backups":             checkInt,
+	"stdout_logfile_maxbytes":            checkBytes,
+	"stdout_syslog":                      nil,
+	"stopasgroup":                        checkBool,
+	"stopsignal":                         checkSignals,
+	"stopwaitsecs":                       checkInt,
+	"syslog_facility":                    checkString,
+	"syslog_stderr_priority":             checkString,
+	"syslog_stdout_priority":             checkString,
+	"syslog_tag":                         checkString,
+	"umask":                              nil,
+	"user":                               checkString,
+	"watchdog_sec":                       checkInt,
+}
+
+// the keys of [eventlistener:x] sections besides the programKeys
+var eventListenerKeys = map[string]keyChecker{
+	"buffer_size":    checkInt,
+	"events":         checkString,
+	"result_handler": nil,
+}
+
+// the keys of the sections with fixed names
+var sectionKeys = map[string]map[string]keyChecker{
+	"supervisord": {
+		"childlogdir":      nil,
+		"directory":        nil,
+		"environment":      nil,
+		"identifier":       checkString,
+		"logfile":          checkString,
+		"logfile_backups":  checkInt,
+		"logfile_maxbytes": checkBytes,
+		"loglevel":         checkChoice("critical", "error", "warn", "info", "debug", "trace", "blather"),
+		"minfds":           checkInt,
+		"minprocs":         checkInt,
+		"nocleanup":        nil,
+		"nodaemon":         nil,
+		"numprocs_file":    checkString,
+		"pidfile":          checkString,
+		"programs_dir":     checkString,
+		"shutdown_timeout": checkInt,
+		"silent":           nil,
+		"strip_ansi":       nil,
+		"umask":            nil,
+		"user":             nil,
+	},
+	"unix_http_server": {
+		"chmod":    nil,
+		"chown":    nil,
+		"file":     checkString,
+		"password": checkString,
+		"username": checkString,
+	},
+	"inet_http_server": {
+		"password": checkString,
+		"port":     checkString,
+		"username": checkString,
+	},
+	"supervisorctl": {
+		"history_file": nil,
+		"password":     checkString,
+		"prompt":       nil,
+		"serverurl":    checkString,
+		"username":     checkString,
+	},
+	"include": {
+		"files": checkString,
+	},
+}
+
+// the keys of [group:x] sections
+var groupKeys = map[string]keyChecker{
+	"priority": checkInt,
+	"programs": checkString,
+}
+
+// get the keys of the section, false if the section is unknown
+func getSectionKeys(name string) (map[string]keyChecker, bool) {
+	switch {
+	case name == "program-default" || strings.HasPrefix(name, "program:"):
+		return programKeys, true
+	case strings.HasPrefix(name, "eventlistener:"):
+		return eventListenerKeys, true
+	case strings.HasPrefix(name, "group:"):
+		return groupKeys, true
+	}
+	keys, ok := sectionKeys[name]
+	return keys, ok
+}
+
+// check if the key can be declared by the indexed keys in the section
+func isIndexedKey(sectionName string, key string) bool {
+	if _, ok := indexedKeys[key]; !ok {
+		return false
+	}
+	return strings.HasPrefix(sectionName, "program:") || strings.HasPrefix(sectionName, "eventlistener:") ||
+		sectionName == "program-default"
+}
+
+// get the checker of the key in the section, the eventlistener sections have the keys of programs also
+func getKeyChecker(sectionName string, keys map[string]keyChecker, key string) (keyChecker, bool) {
+	if checker, ok := keys[key]; ok {
+		return checker, true
+	}
+	if strings.HasPrefix(sectionName, "eventlistener:") {
+		checker, ok := programKeys[key]
+		return checker, ok
+	}
+	return nil, false
+}
+
+// find the most similar key to suggest for an unknown key, empty if none is similar enough
+func suggestKey(key string, sectionName string, keys map[string]keyChecker) string {
+	candidates := make([]string, 0, len(keys))
+	for k := range keys {
+		candidates = append(candidates, k)
+	}
+	if strings.HasPrefix(sectionName, "eventlistener:") {
+		for k := range programKeys {
+			candidates = append(candidates, k)
+		}
+	}
+	sort.Strings(candidates)
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(candidate)); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// the Levenshtein distance between two strings
+func editDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// create the expressions to check the values of the section
+func (c *Config) newCheckContext(section *ini.Section, groups *ProcessGroup) *checkContext {
+	ctx := &checkContext{here: NewStringExpression("here", c.GetConfigFileDir())}
+	programName := section.Name
+	if pos := strings.Index(programName, ":"); pos != -1 {
+		programName = programName[pos+1:]
+	}
+	ctx.program = NewStringExpression("program_name", programName,
+		"process_num", "1",
+		"group_name", groups.GetGroup(programName, programName),
+		"here", c.GetConfigFileDir())
+	for k, v := range getEnvironment("environment", sectionKeyValues(section)) {
+		ctx.program.Add(fmt.Sprintf("ENV_%s", k), v)
+	}
+	return ctx
+}
+
+// validate the sections loaded from the files before they are parsed
+func (c *Config) validate(cfg *ini.Ini, locations *iniLocations) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0)
+	if !cfg.HasSection("supervisord") {
+		diagnostics = append(diagnostics, Diagnostic{File: c.configFile, Section: "supervisord", Severity: SeverityWarning,
+			Message: "section is missing, the default settings are used"})
+	}
+	groups := NewConfig(c.configFile)
+	groups.parseGroup(cfg)
+	defaults, _ := cfg.GetSection("program-default")
+	for _, section := range cfg.Sections() {
+		diagnostics = append(diagnostics, c.validateSection(section, defaults, locations, groups.ProgramGroup, nil)...)
+	}
+	return diagnostics
+}
+
+// get the value of the key in the program section or in the [program-default] section
+func getProgramValue(section *ini.Section, defaults *ini.Section, key string) string {
+	if !section.HasKey(key) && defaults != nil {
+		return strings.TrimSpace(defaults.GetValueWithDefault(key, ""))
+	}
+	return strings.TrimSpace(section.GetValueWithDefault(key, ""))
+}
+
+// validate the keys of the section against its schema, defaults is the [program-default] section or
+// nil. The unknown or unsupported keys in strictKeys are errors instead of warnings
+func (c *Config) validateSection(section *ini.Section, defaults *ini.Section, locations *iniLocations, groups *ProcessGroup, strictKeys map[string]string) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0)
+	keys, ok := getSectionKeys(section.Name)
+	if !ok {
+		return append(diagnostics, locations.newDiagnostic(section.Name, "", SeverityWarning, "unknown section is ignored"))
+	}
+	ctx := c.newCheckContext(section, groups)
+	for _, key := range section.Keys() {
+		name := key.Name()
+		value := strings.TrimSpace(key.ValueWithDefault(""))
+		if indexedName, index, ok := splitIndexedKey(name); ok && isIndexedKey(section.Name, indexedName) {
+			if err := checkIndex(indexedName, index); err != nil {
+				diagnostics = append(diagnostics, locations.newDiagnostic(section.Name, name, SeverityError, "%v", err))
+			} else if err := checkExpression(value, ctx); err != nil {
+				diagnostics = append(diagnostics, locations.newDiagnostic(section.Name, name, SeverityError, "%v", err))
+			}
+			continue
+		}
+		// the strict keys are rejected instead of being ignored if they are unknown or not supported
+		severity, unknown, unsupported := SeverityWarning, "unknown key is ignored", "key is not supported and ignored"
+		if _, ok := strictKeys[name]; ok {
+			severity, unknown, unsupported = SeverityError, "unknown key", "key is not supported"
+		}
+		checker, ok := getKeyChecker(section.Name, keys, name)
+		if !ok {
+			message := unknown
+			if suggestion := suggestKey(name, section.Name, keys); suggestion != "" {
+				message = fmt.Sprintf("%s, did you mean %s?", unknown, suggestion)
+			}
+			diagnostics = append(diagnostics, locations.newDiagnostic(section.Name, name, severity, message))
+		} else if checker == nil {
+			diagnostics = append(diagnostics, locations.newDiagnostic(section.Name, name, severity, unsupported))
+		} else if err := checker(value, ctx); err != nil {
+			diagnostics = append(diagnostics, locations.newDiagnostic(section.Name, name, SeverityError, "%v", err))
+		}
+	}
+
+	if isIndexedKey(section.Name, "command") {
+		if name, err := checkIndexedArray("command", sectionKeyValues(section)); err != nil {
+			diagnostics = append(diagnostics, locations.newDiagnostic(section.Name, name, SeverityError, "%v", err))
+		}
+	}
+	if strings.HasPrefix(section.Name, "program:") || strings.HasPrefix(section.Name, "eventlistener:") {
+		argv := hasIndexedKeys("command", sectionKeyValues(section))
+		if argv && section.HasKey("command") {
+			diagnostics = append(diagnostics, locations.newDiagnostic(section.Name, "command", SeverityError,
+				"command is declared both as a command line and as an argv array by command[0], command[1]..."))
+		} else if !argv && getProgramValue(section, defaults, "command") == "" {
+			diagnostics = append(diagnostics, locations.newDiagnostic(section.Name, "command", SeverityError, "command is missing"))
+		}
+		numProcs, err := strconv.Atoi(getProgramValue(section, defaults, "numprocs"))
+		processName := getProgramValue(section, defaults, "process_name")
+		if err == nil && numProcs > 1 && !strings.Contains(processName, "%(process_num)") {
+			diagnostics = append(diagnostics, locations.newDiagnostic(section.Name, "process_name", SeverityError,
+				"process_name must contain %%(process_num) to run %d instances", numProcs))
+		}
+	}
+	return diagnostics
+}
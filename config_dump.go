@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ochinchina/supervisord/config"
+)
+
+// ConfigCommand the config subcommand groups the commands inspecting the configuration files
+type ConfigCommand struct{}
+
+// ConfigDumpCommand prints the effective configuration of the programs and where every value comes from
+type ConfigDumpCommand struct {
+	Format string `short:"f" long:"format" choice:"ini" choice:"json" default:"ini" description:"the output format"`
+}
+
+// ConfigConvertCommand prints the ini configuration file in YAML or TOML
+type ConfigConvertCommand struct {
+	Format string `short:"f" long:"format" choice:"yaml" choice:"toml" default:"yaml" description:"the output format"`
+}
+
+var (
+	configCommand        ConfigCommand
+	configDumpCommand    ConfigDumpCommand
+	configConvertCommand ConfigConvertCommand
+)
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (c ConfigDumpCommand) Execute(args []string) error {
+	loadEnvFile()
+	configFile, err := findSupervisordConf()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cfg := config.NewConfig(configFile)
+	diagnostics := cfg.Check()
+	for _, d := range diagnostics {
+		if d.Severity == config.SeverityError {
+			fmt.Fprintln(os.Stderr, d.String())
+		}
+	}
+	programs := cfg.GetEffectivePrograms(args...)
+	if len(args) > 0 && len(programs) == 0 {
+		fmt.Fprintf(os.Stderr, "no program named %s\n", strings.Join(args, ", "))
+		os.Exit(1)
+	}
+	if err := writeEffectivePrograms(os.Stdout, programs, c.Format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if config.HasErrors(diagnostics) {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// write the effective configuration of the programs in ini or json format
+func writeEffectivePrograms(w io.Writer, programs []config.EffectiveProgram, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(programs)
+	}
+	for i, program := range programs {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if err := program.WriteINI(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (c ConfigConvertCommand) Execute(args []string) error {
+	loadEnvFile()
+	if len(args) > 0 {
+		options.Configuration = args[0]
+	}
+	configFile, err := findSupervisordConf()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	content, skipped, err := config.ConvertIniFile(configFile, c.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, section := range skipped {
+		fmt.Fprintf(os.Stderr, "section [%s] is not converted\n", section)
+	}
+	_, err = os.Stdout.Write(content)
+	return err
+}
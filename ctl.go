@@ -0,0 +1,727 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ochinchina/supervisord/config"
+	"github.com/ochinchina/supervisord/faults"
+	"github.com/ochinchina/supervisord/process"
+	"github.com/ochinchina/supervisord/types"
+	"github.com/ochinchina/supervisord/xmlrpcclient"
+)
+
+// exit codes of the ctl command, follow the LSB init script conventions
+const (
+	ctlExitOK            = 0
+	ctlExitFailure       = 1
+	ctlExitInvalidArgs   = 2
+	ctlExitNotRunning    = 3
+	ctlExitNoSuchProcess = 4
+)
+
+const (
+	defaultTailLogLength  = 1600
+	tailFollowPollingTime = time.Second
+)
+
+// CtlCommand the entry of the ctl command which controls a running supervisord
+// through its xml rpc interface
+type CtlCommand struct {
+	ServerURL string `short:"s" long:"serverurl" description:"URL on which supervisord server is listening, e.g. unix:///tmp/supervisord.sock or http://127.0.0.1:9001"`
+	User      string `short:"u" long:"user" description:"the user name to use for authentication with the server"`
+	Password  string `short:"P" long:"password" description:"the password to use for authentication with the server"`
+}
+
+// StatusCommand shows the status of the processes
+type StatusCommand struct{}
+
+// StartCommand starts the processes
+type StartCommand struct{}
+
+// StopCommand stops the processes
+type StopCommand struct{}
+
+// RestartCommand restarts the processes
+type RestartCommand struct{}
+
+// SignalCommand sends a signal to the processes
+type SignalCommand struct{}
+
+// ResetBreakerCommand clears the restart history of the processes exceeding their restart limit
+type ResetBreakerCommand struct{}
+
+// TailCommand shows the tail of the process log
+type TailCommand struct {
+	Follow bool `short:"f" long:"follow" description:"keep printing the log as it grows"`
+	Bytes  int  `short:"n" long:"bytes" default:"1600" description:"the number of bytes to show from the end of the log"`
+}
+
+// GroupCommand starts, stops or restarts the groups in the depends_on/priority order
+type GroupCommand struct {
+	Timeout int `short:"t" long:"timeout" default:"0" description:"the seconds to wait for the whole group, 0 for the server default"`
+}
+
+// ScaleCommand changes the number of the instances of a program
+type ScaleCommand struct {
+	Persist bool `short:"p" long:"persist" description:"keep the numprocs across reloads and save it to numprocs_file"`
+}
+
+// AddProgramCommand adds a program at runtime
+type AddProgramCommand struct {
+	Persist bool `short:"p" long:"persist" description:"save the program to programs_dir so it is kept across reloads"`
+}
+
+// RemoveProgramCommand stops and removes a program at runtime
+type RemoveProgramCommand struct{}
+
+// RollingRestartCommand restarts the instances of a program one batch at a time
+type RollingRestartCommand struct {
+	Batch   int `short:"b" long:"batch" default:"1" description:"the number of instances restarted at the same time"`
+	Timeout int `short:"t" long:"timeout" default:"0" description:"the seconds to wait for every batch, 0 for the server default"`
+}
+
+// ReloadCommand reloads the configuration of supervisord
+type ReloadCommand struct{}
+
+// ShutdownCommand shuts down supervisord
+type ShutdownCommand struct{}
+
+// PidCommand shows the pid of supervisord or the processes
+type PidCommand struct{}
+
+var (
+	ctlCommand            CtlCommand
+	statusCommand         StatusCommand
+	startCommand          StartCommand
+	stopCommand           StopCommand
+	restartCommand        RestartCommand
+	signalCommand         SignalCommand
+	resetBreakerCommand   ResetBreakerCommand
+	tailCommand           TailCommand
+	groupCommand          GroupCommand
+	scaleCommand          ScaleCommand
+	rollingRestartCommand RollingRestartCommand
+	addProgramCommand     AddProgramCommand
+	removeProgramCommand  RemoveProgramCommand
+	reloadCommand         ReloadCommand
+	shutdownCommand       ShutdownCommand
+	pidCommand            PidCommand
+)
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (cc *CtlCommand) Execute(_ []string) error {
+	// the ctl command itself is never executed because a sub-command is always required
+	return nil
+}
+
+// get the server url, user and password from command line or the configuration file.
+// The server url is found in following order:
+//
+// 1. the --serverurl option
+// 2. serverurl in [supervisorctl] section
+// 3. port in [inet_http_server] section
+// 4. file in [unix_http_server] section
+func (cc *CtlCommand) getServerURL() (string, string, string) {
+	serverURL, user, password := cc.ServerURL, cc.User, cc.Password
+	if serverURL != "" {
+		return serverURL, user, password
+	}
+
+	configFile := options.Configuration
+	if configFile == "" {
+		configFile, _ = findSupervisordConf()
+	}
+	if configFile == "" {
+		return "http://localhost:9001", user, password
+	}
+	// only the server settings are read, the other sections are checked by the check command
+	myini := config.LoadIniFile(configFile)
+
+	if serverURL = myini.GetValueWithDefault("supervisorctl", "serverurl", ""); serverURL != "" {
+		user = defaultString(user, myini.GetValueWithDefault("supervisorctl", "username", ""))
+		password = defaultString(password, myini.GetValueWithDefault("supervisorctl", "password", ""))
+		if strings.HasPrefix(serverURL, "unix://") {
+			return serverURL, user, password
+		}
+		serverURL = strings.TrimPrefix(serverURL, "http://")
+		if strings.HasPrefix(serverURL, ":") {
+			serverURL = "localhost" + serverURL
+		}
+		return "http://" + serverURL, user, password
+	}
+
+	if addr := myini.GetValueWithDefault("inet_http_server", "port", ""); addr != "" {
+		if strings.HasPrefix(addr, ":") {
+			addr = "localhost" + addr
+		}
+		return "http://" + addr,
+			defaultString(user, myini.GetValueWithDefault("inet_http_server", "username", "")),
+			defaultString(password, myini.GetValueWithDefault("inet_http_server", "password", ""))
+	}
+
+	if myini.HasSection("unix_http_server") {
+		env := config.NewStringExpression("here", filepath.Dir(configFile))
+		sockFile, err := env.Eval(myini.GetValueWithDefault("unix_http_server", "file", "/tmp/supervisord.sock"))
+		if err == nil {
+			return "unix://" + sockFile,
+				defaultString(user, myini.GetValueWithDefault("unix_http_server", "username", "")),
+				defaultString(password, myini.GetValueWithDefault("unix_http_server", "password", ""))
+		}
+	}
+	return "http://localhost:9001", user, password
+}
+
+func defaultString(s string, defValue string) string {
+	if s == "" {
+		return defValue
+	}
+	return s
+}
+
+// create the xml rpc client to the running supervisord
+func (cc *CtlCommand) createRPCClient() *xmlrpcclient.XMLRPCClient {
+	serverURL, user, password := cc.getServerURL()
+	rpcc := xmlrpcclient.NewXMLRPCClient(serverURL)
+	rpcc.SetUser(user)
+	rpcc.SetPassword(password)
+	// the xml rpc call may wait for the processes to start or stop
+	rpcc.SetTimeout(0)
+	return rpcc
+}
+
+// get the name of process shown to the user, the group is omitted if it is same as the program
+func processDisplayName(info types.ProcessInfo) string {
+	if info.Group == "" || info.Group == info.Name {
+		return info.Name
+	}
+	return info.GetFullName()
+}
+
+// find the processes matching the names. A name can be "all", "group:*", "group:program" or "program".
+// The names without any matched process are returned as the second value
+func matchProcesses(infos []types.ProcessInfo, names []string) ([]types.ProcessInfo, []string) {
+	result := make([]types.ProcessInfo, 0)
+	unmatched := make([]string, 0)
+	added := make(map[string]bool)
+	for _, name := range names {
+		found := false
+		for _, info := range infos {
+			if matchProcessName(info, name) {
+				found = true
+				if !added[info.GetFullName()] {
+					added[info.GetFullName()] = true
+					result = append(result, info)
+				}
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, name)
+		}
+	}
+	return result, unmatched
+}
+
+func matchProcessName(info types.ProcessInfo, name string) bool {
+	if name == "all" {
+		return true
+	}
+	if pos := strings.Index(name, ":"); pos != -1 {
+		groupName := name[0:pos]
+		programName := name[pos+1:]
+		return info.Group == groupName && (programName == "*" || programName == info.Name)
+	}
+	return info.Name == name
+}
+
+// get all the process information sorted by the display name
+func getSortedProcessInfo(rpcc *xmlrpcclient.XMLRPCClient) ([]types.ProcessInfo, error) {
+	infos, err := rpcc.GetAllProcessInfo()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return processDisplayName(infos[i]) < processDisplayName(infos[j])
+	})
+	return infos, nil
+}
+
+// get the processes matching the names, print error for the names without matched process
+func (cc *CtlCommand) getMatchedProcesses(rpcc *xmlrpcclient.XMLRPCClient, names []string) ([]types.ProcessInfo, int) {
+	infos, err := getSortedProcessInfo(rpcc)
+	if err != nil {
+		return nil, ctlError(err)
+	}
+	matched, unmatched := matchProcesses(infos, names)
+	for _, name := range unmatched {
+		fmt.Fprintf(os.Stderr, "%s: ERROR (no such process)\n", name)
+	}
+	if len(unmatched) > 0 {
+		return matched, ctlExitNoSuchProcess
+	}
+	return matched, ctlExitOK
+}
+
+// print the error and return the exit code mapped from the error
+func ctlError(err error) int {
+	fmt.Fprintln(os.Stderr, formatCtlError(err))
+	return ctlErrorCode(err)
+}
+
+func formatCtlError(err error) string {
+	if fault, ok := err.(*xmlrpcclient.Fault); ok {
+		return fmt.Sprintf("ERROR (%s)", faultDescription(fault))
+	}
+	return fmt.Sprintf("ERROR (%v)", err)
+}
+
+func faultDescription(fault *xmlrpcclient.Fault) string {
+	switch fault.Code {
+	case faults.BadName:
+		return "no such process"
+	case faults.AlreadyStated:
+		return "already started"
+	case faults.NotRunning:
+		return "not running"
+	case faults.SpawnError:
+		return "spawn error"
+	case faults.NoFile:
+		return "no log file"
+	case faults.BadSignal:
+		return "bad signal"
+	}
+	return strings.ToLower(fault.String)
+}
+
+func ctlErrorCode(err error) int {
+	if fault, ok := err.(*xmlrpcclient.Fault); ok {
+		switch fault.Code {
+		case faults.BadName:
+			return ctlExitNoSuchProcess
+		case faults.BadSignal, faults.BadArguments, faults.IncorrectParameters:
+			return ctlExitInvalidArgs
+		}
+	}
+	return ctlExitFailure
+}
+
+// keep the worst exit code
+func worseExitCode(code1 int, code2 int) int {
+	if code1 > code2 {
+		return code1
+	}
+	return code2
+}
+
+// exit the supervisord process if the ctl command fails
+func ctlExit(code int) error {
+	if code != ctlExitOK {
+		os.Exit(code)
+	}
+	return nil
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (sc *StatusCommand) Execute(args []string) error {
+	rpcc := ctlCommand.createRPCClient()
+	infos, err := getSortedProcessInfo(rpcc)
+	if err != nil {
+		return ctlExit(ctlError(err))
+	}
+	exitCode := ctlExitOK
+	if len(args) > 0 {
+		var unmatched []string
+		infos, unmatched = matchProcesses(infos, args)
+		for _, name := range unmatched {
+			fmt.Printf("%s: ERROR (no such process)\n", name)
+			exitCode = ctlExitNoSuchProcess
+		}
+	}
+
+	nameWidth := 0
+	for _, info := range infos {
+		if len(processDisplayName(info)) > nameWidth {
+			nameWidth = len(processDisplayName(info))
+		}
+	}
+	for _, info := range infos {
+		description := info.Description
+		if info.Health != "" && info.Health != "none" {
+			description = fmt.Sprintf("%s, health %s", description, info.Health)
+		}
+		if info.NextAttempt > 0 {
+			description = fmt.Sprintf("%s, next attempt in %.0fs", description, time.Until(time.Unix(int64(info.NextAttempt), 0)).Seconds())
+		}
+		fmt.Printf("%-*s   %-10s %s\n", nameWidth, processDisplayName(info), strings.ToUpper(info.Statename), description)
+		if info.Statename != "Running" {
+			exitCode = worseExitCode(exitCode, ctlExitNotRunning)
+		}
+	}
+	return ctlExit(exitCode)
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (sc *StartCommand) Execute(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: start requires a process name, e.g. start <name>, start <group>:*, start all")
+		return ctlExit(ctlExitInvalidArgs)
+	}
+	rpcc := ctlCommand.createRPCClient()
+	procs, exitCode := ctlCommand.getMatchedProcesses(rpcc, args)
+	for _, info := range procs {
+		exitCode = worseExitCode(exitCode, startRemoteProcess(rpcc, info))
+	}
+	return ctlExit(exitCode)
+}
+
+func startRemoteProcess(rpcc *xmlrpcclient.XMLRPCClient, info types.ProcessInfo) int {
+	if err := rpcc.StartProcess(info.GetFullName(), true); err != nil {
+		fmt.Printf("%s: %s\n", processDisplayName(info), formatCtlError(err))
+		if fault, ok := err.(*xmlrpcclient.Fault); ok && fault.Code == faults.AlreadyStated {
+			return ctlExitOK
+		}
+		return ctlErrorCode(err)
+	}
+	fmt.Printf("%s: started\n", processDisplayName(info))
+	return ctlExitOK
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (sc *StopCommand) Execute(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: stop requires a process name, e.g. stop <name>, stop <group>:*, stop all")
+		return ctlExit(ctlExitInvalidArgs)
+	}
+	rpcc := ctlCommand.createRPCClient()
+	procs, exitCode := ctlCommand.getMatchedProcesses(rpcc, args)
+	for _, info := range procs {
+		exitCode = worseExitCode(exitCode, stopRemoteProcess(rpcc, info))
+	}
+	return ctlExit(exitCode)
+}
+
+func stopRemoteProcess(rpcc *xmlrpcclient.XMLRPCClient, info types.ProcessInfo) int {
+	if err := rpcc.StopProcess(info.GetFullName(), true); err != nil {
+		fmt.Printf("%s: %s\n", processDisplayName(info), formatCtlError(err))
+		if fault, ok := err.(*xmlrpcclient.Fault); ok && fault.Code == faults.NotRunning {
+			return ctlExitOK
+		}
+		return ctlErrorCode(err)
+	}
+	fmt.Printf("%s: stopped\n", processDisplayName(info))
+	return ctlExitOK
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (rc *RestartCommand) Execute(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: restart requires a process name, e.g. restart <name>, restart <group>:*, restart all")
+		return ctlExit(ctlExitInvalidArgs)
+	}
+	rpcc := ctlCommand.createRPCClient()
+	procs, exitCode := ctlCommand.getMatchedProcesses(rpcc, args)
+	for _, info := range procs {
+		if isRunningState(process.State(info.State)) {
+			exitCode = worseExitCode(exitCode, stopRemoteProcess(rpcc, info))
+		}
+	}
+	for _, info := range procs {
+		exitCode = worseExitCode(exitCode, startRemoteProcess(rpcc, info))
+	}
+	return ctlExit(exitCode)
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (sc *SignalCommand) Execute(args []string) error {
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, "Error: signal requires a signal and a process name, e.g. signal HUP <name>")
+		return ctlExit(ctlExitInvalidArgs)
+	}
+	rpcc := ctlCommand.createRPCClient()
+	sig := args[0]
+	procs, exitCode := ctlCommand.getMatchedProcesses(rpcc, args[1:])
+	for _, info := range procs {
+		if err := rpcc.SignalProcess(info.GetFullName(), sig); err != nil {
+			fmt.Printf("%s: %s\n", processDisplayName(info), formatCtlError(err))
+			exitCode = worseExitCode(exitCode, ctlErrorCode(err))
+		} else {
+			fmt.Printf("%s: signalled\n", processDisplayName(info))
+		}
+	}
+	return ctlExit(exitCode)
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (rc *ResetBreakerCommand) Execute(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: reset-breaker requires a process name, e.g. reset-breaker <name>, reset-breaker <group>:*")
+		return ctlExit(ctlExitInvalidArgs)
+	}
+	rpcc := ctlCommand.createRPCClient()
+	exitCode := ctlExitOK
+	for _, name := range args {
+		results, err := rpcc.ResetRestartBreaker(name)
+		if err != nil {
+			fmt.Printf("%s: %s\n", name, formatCtlError(err))
+			exitCode = worseExitCode(exitCode, ctlErrorCode(err))
+			continue
+		}
+		for _, result := range results {
+			display := processDisplayName(types.ProcessInfo{Name: result.Name, Group: result.Group})
+			if result.Description == "OK" {
+				fmt.Printf("%s: reset\n", display)
+			} else {
+				fmt.Printf("%s: not tripped\n", display)
+			}
+		}
+	}
+	return ctlExit(exitCode)
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (tc *TailCommand) Execute(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		fmt.Fprintln(os.Stderr, "Error: tail requires a process name and an optional stream, e.g. tail [-f] <name> [stdout|stderr]")
+		return ctlExit(ctlExitInvalidArgs)
+	}
+	stream := "stdout"
+	if len(args) == 2 {
+		stream = args[1]
+		if stream != "stdout" && stream != "stderr" {
+			fmt.Fprintf(os.Stderr, "Error: bad stream %s, must be stdout or stderr\n", stream)
+			return ctlExit(ctlExitInvalidArgs)
+		}
+	}
+	if tc.Bytes <= 0 {
+		tc.Bytes = defaultTailLogLength
+	}
+
+	rpcc := ctlCommand.createRPCClient()
+	name := args[0]
+	// get the length of log file by reading from the end
+	_, logLength, _, err := rpcc.TailProcessLog(name, stream, math.MaxInt32, 0)
+	if err != nil {
+		return ctlExit(ctlError(err))
+	}
+	offset := logLength - tc.Bytes
+	if offset < 0 {
+		offset = 0
+	}
+	data, offset, _, err := rpcc.TailProcessLog(name, stream, offset, tc.Bytes)
+	if err != nil {
+		return ctlExit(ctlError(err))
+	}
+	fmt.Print(data)
+	for tc.Follow {
+		time.Sleep(tailFollowPollingTime)
+		data, offset, _, err = rpcc.TailProcessLog(name, stream, offset, tc.Bytes)
+		if err != nil {
+			return ctlExit(ctlError(err))
+		}
+		fmt.Print(data)
+	}
+	return nil
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (gc *GroupCommand) Execute(args []string) error {
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, "Error: group requires an action and a group name, e.g. group start|stop|restart <group>")
+		return ctlExit(ctlExitInvalidArgs)
+	}
+	rpcc := ctlCommand.createRPCClient()
+	var operation func(string, int) ([]types.TaskResult, error)
+	successText := ""
+	switch args[0] {
+	case "start":
+		operation, successText = rpcc.StartProcessGroup, "started"
+	case "stop":
+		operation, successText = rpcc.StopProcessGroup, "stopped"
+	case "restart":
+		operation, successText = rpcc.RestartProcessGroup, "restarted"
+	default:
+		fmt.Fprintf(os.Stderr, "Error: bad group action %s, must be start, stop or restart\n", args[0])
+		return ctlExit(ctlExitInvalidArgs)
+	}
+
+	exitCode := ctlExitOK
+	for _, group := range args[1:] {
+		results, err := operation(group, gc.Timeout)
+		if err != nil {
+			fmt.Printf("%s: %s\n", group, formatCtlError(err))
+			exitCode = worseExitCode(exitCode, ctlErrorCode(err))
+			continue
+		}
+		for _, result := range results {
+			name := processDisplayName(types.ProcessInfo{Name: result.Name, Group: result.Group})
+			switch result.Status {
+			case faults.Success:
+				fmt.Printf("%s: %s\n", name, successText)
+			case faults.AlreadyStated, faults.NotRunning:
+				fmt.Printf("%s: %s\n", name, formatCtlError(&xmlrpcclient.Fault{Code: result.Status, String: result.Description}))
+			default:
+				fmt.Printf("%s: ERROR (%s)\n", name, strings.ToLower(result.Description))
+				exitCode = worseExitCode(exitCode, ctlExitFailure)
+			}
+		}
+	}
+	return ctlExit(exitCode)
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (rc *RollingRestartCommand) Execute(args []string) error {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Error: rolling-restart requires a program name, e.g. rolling-restart [-b batch] <program>")
+		return ctlExit(ctlExitInvalidArgs)
+	}
+	rpcc := ctlCommand.createRPCClient()
+	results, err := rpcc.RollingRestartProgram(args[0], rc.Batch, rc.Timeout)
+	if err != nil {
+		fmt.Printf("%s: %s\n", args[0], formatCtlError(err))
+		return ctlExit(ctlErrorCode(err))
+	}
+	exitCode := ctlExitOK
+	for _, result := range results {
+		name := processDisplayName(types.ProcessInfo{Name: result.Name, Group: result.Group})
+		if result.Status == faults.Success {
+			fmt.Printf("%s: restarted\n", name)
+		} else {
+			fmt.Printf("%s: ERROR (%s)\n", name, strings.ToLower(result.Description))
+			exitCode = worseExitCode(exitCode, ctlExitFailure)
+		}
+	}
+	return ctlExit(exitCode)
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (ac *AddProgramCommand) Execute(args []string) error {
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, "Error: add-program requires a program name and its settings, e.g. add-program <program> command=<command> [key=value...]")
+		return ctlExit(ctlExitInvalidArgs)
+	}
+	rpcc := ctlCommand.createRPCClient()
+	result, err := rpcc.AddProgram(args[0], args[1:], ac.Persist)
+	if err != nil {
+		fmt.Printf("%s: %s\n", args[0], formatCtlError(err))
+		return ctlExit(ctlErrorCode(err))
+	}
+	for _, name := range result.Processes {
+		fmt.Printf("%s: added\n", name)
+	}
+	return nil
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (rc *RemoveProgramCommand) Execute(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: remove-program requires a program name, e.g. remove-program <program>")
+		return ctlExit(ctlExitInvalidArgs)
+	}
+	rpcc := ctlCommand.createRPCClient()
+	exitCode := ctlExitOK
+	for _, name := range args {
+		result, err := rpcc.RemoveProgram(name)
+		if err != nil {
+			fmt.Printf("%s: %s\n", name, formatCtlError(err))
+			exitCode = worseExitCode(exitCode, ctlErrorCode(err))
+			continue
+		}
+		for _, procName := range result.Processes {
+			fmt.Printf("%s: removed\n", procName)
+		}
+	}
+	return ctlExit(exitCode)
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (rc *ReloadCommand) Execute(_ []string) error {
+	rpcc := ctlCommand.createRPCClient()
+	result, err := rpcc.ReloadConfig()
+	if err != nil {
+		return ctlExit(ctlError(err))
+	}
+	for _, name := range result.AddedGroup {
+		fmt.Printf("%s: added process group\n", name)
+	}
+	for _, name := range result.ChangedGroup {
+		fmt.Printf("%s: changed\n", name)
+	}
+	for _, name := range result.RemovedGroup {
+		fmt.Printf("%s: removed process group\n", name)
+	}
+	for _, name := range result.ChangedProgram {
+		fmt.Printf("%s: updated\n", name)
+	}
+	if len(result.AddedGroup)+len(result.ChangedGroup)+len(result.RemovedGroup) == 0 {
+		fmt.Println("No config updates to processes")
+	}
+	return nil
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (sc *ShutdownCommand) Execute(_ []string) error {
+	rpcc := ctlCommand.createRPCClient()
+	if err := rpcc.Shutdown(); err != nil {
+		return ctlExit(ctlError(err))
+	}
+	fmt.Println("Shut down")
+	return nil
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (sc *ScaleCommand) Execute(args []string) error {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Error: scale requires a program name and the number of instances, e.g. scale <program> <numprocs>")
+		return ctlExit(ctlExitInvalidArgs)
+	}
+	numprocs, err := strconv.Atoi(args[1])
+	if err != nil || numprocs < 0 {
+		fmt.Fprintf(os.Stderr, "Error: bad number of instances %s\n", args[1])
+		return ctlExit(ctlExitInvalidArgs)
+	}
+	rpcc := ctlCommand.createRPCClient()
+	result, err := rpcc.ScaleProgram(args[0], numprocs, sc.Persist)
+	if err != nil {
+		fmt.Printf("%s: %s\n", args[0], formatCtlError(err))
+		return ctlExit(ctlErrorCode(err))
+	}
+	for _, name := range result.Added {
+		fmt.Printf("%s: added\n", name)
+	}
+	for _, name := range result.Removed {
+		fmt.Printf("%s: removed\n", name)
+	}
+	fmt.Printf("%s: scaled to %d\n", result.Program, result.Numprocs)
+	return nil
+}
+
+// Execute implement Execute() method defined in flags.Commander interface, executes the given command
+func (pc *PidCommand) Execute(args []string) error {
+	rpcc := ctlCommand.createRPCClient()
+	if len(args) == 0 {
+		pid, err := rpcc.GetPID()
+		if err != nil {
+			return ctlExit(ctlError(err))
+		}
+		fmt.Println(pid)
+		return nil
+	}
+	procs, exitCode := ctlCommand.getMatchedProcesses(rpcc, args)
+	for _, info := range procs {
+		if len(procs) > 1 {
+			fmt.Printf("%s: %d\n", processDisplayName(info), info.Pid)
+		} else {
+			fmt.Println(info.Pid)
+		}
+		if info.Pid == 0 {
+			exitCode = worseExitCode(exitCode, ctlExitNotRunning)
+		}
+	}
+	return ctlExit(exitCode)
+}
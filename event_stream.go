@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ochinchina/supervisord/events"
+)
+
+// the max number of events buffered for one event stream client
+const eventStreamBufferSize = 1024
+
+// CreateEventHandler create http handler to stream the supervisor events
+func (sr *SupervisorRestful) CreateEventHandler() http.Handler {
+	sr.router.HandleFunc("/events", sr.StreamEvents).Methods("GET")
+	return sr.router
+}
+
+// split the comma separated query parameter values
+func getListQuery(req *http.Request, name string) []string {
+	result := make([]string, 0)
+	for _, value := range req.URL.Query()[name] {
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
+
+// check if the event is about one of the programs and groups. The events not related to
+// any process like TICK_5 are always accepted
+func matchEventProcess(fields map[string]interface{}, programs []string, groups []string) bool {
+	processName, ok := fields["processname"].(string)
+	if !ok {
+		return true
+	}
+	groupName, _ := fields["groupname"].(string)
+	if len(programs) > 0 && !containsString(programs, processName) && !containsString(programs, groupName+":"+processName) {
+		return false
+	}
+	return len(groups) == 0 || containsString(groups, groupName)
+}
+
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+// StreamEvents streams the process state, tick, process communication and log events in json.
+//
+// Query parameters:
+//
+//	type - the event types, abstract types like PROCESS_STATE are accepted, default is all the events
+//	program - only the events of these programs ("name" or "group:name") are streamed
+//	group - only the events of processes in these groups are streamed
+//	format - "sse" for server-sent events (default) or "jsonl" for one json object per line
+//
+// The log events are only emitted for the programs with stdout_events_enabled/stderr_events_enabled
+func (sr *SupervisorRestful) StreamEvents(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+	eventTypes := getListQuery(req, "type")
+	if len(eventTypes) == 0 {
+		eventTypes = []string{"EVENT"}
+	}
+	programs := getListQuery(req, "program")
+	groups := getListQuery(req, "group")
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = "sse"
+	}
+	if format != "sse" && format != "jsonl" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid format %s, must be sse or jsonl", format))
+		return
+	}
+
+	subscription, err := events.Subscribe(eventTypes, eventStreamBufferSize)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer subscription.Close()
+
+	if format == "sse" {
+		w.Header().Set("Content-Type", "text/event-stream")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case event := <-subscription.Events():
+			fields := events.Fields(event)
+			if !matchEventProcess(fields, programs, groups) {
+				continue
+			}
+			b, _ := json.Marshal(fields)
+			if format == "sse" {
+				_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", event.GetSerial(), b)
+			} else {
+				_, err = fmt.Fprintf(w, "%s\n", b)
+			}
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestMatchEventProcess(t *testing.T) {
+	web := map[string]interface{}{"processname": "web", "groupname": "frontend"}
+	db := map[string]interface{}{"processname": "db", "groupname": "db"}
+	tick := map[string]interface{}{"when": 1}
+
+	tests := []struct {
+		name     string
+		fields   map[string]interface{}
+		programs []string
+		groups   []string
+		want     bool
+	}{
+		{"no filter", web, nil, nil, true},
+		{"tick is always accepted", tick, []string{"db"}, []string{"db"}, true},
+		{"program name", web, []string{"web"}, nil, true},
+		{"group and program name", web, []string{"frontend:web"}, nil, true},
+		{"other program", web, []string{"db"}, nil, false},
+		{"group", web, nil, []string{"frontend"}, true},
+		{"other group", web, nil, []string{"backend"}, false},
+		{"ungrouped program is its own group", db, nil, []string{"db"}, true},
+		{"ungrouped program with its group", db, []string{"db:db"}, nil, true},
+		{"program and group", web, []string{"web"}, []string{"backend"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := matchEventProcess(test.fields, test.programs, test.groups); got != test.want {
+				t.Errorf("matchEventProcess(%v, %v, %v) = %v, want %v", test.fields, test.programs, test.groups, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package events
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EventSubscription receives the emitted events in process. It is used by the API clients
+// to observe the events without starting an event listener program
+type EventSubscription struct {
+	events    map[string]bool
+	eventChan chan Event
+}
+
+// eventSubscriptionManager manage all the event subscriptions
+type eventSubscriptionManager struct {
+	lock          sync.Mutex
+	subscriptions map[*EventSubscription]bool
+}
+
+var eventSubscriptions = &eventSubscriptionManager{subscriptions: make(map[*EventSubscription]bool)}
+
+// Subscribe creates an EventSubscription to receive the events. The events can be final events
+// like "PROCESS_STATE_RUNNING" or abstract events like "PROCESS_STATE" and "EVENT". At most
+// bufferSize events are buffered, the events are discarded if the subscriber is too slow
+func Subscribe(events []string, bufferSize int) (*EventSubscription, error) {
+	for _, event := range events {
+		if len(expandEventTypes([]string{event})) == 0 {
+			return nil, fmt.Errorf("unknown event type %s", event)
+		}
+	}
+	subscription := &EventSubscription{
+		events:    expandEventTypes(events),
+		eventChan: make(chan Event, bufferSize),
+	}
+	eventSubscriptions.lock.Lock()
+	defer eventSubscriptions.lock.Unlock()
+	eventSubscriptions.subscriptions[subscription] = true
+	return subscription, nil
+}
+
+// Events returns the channel to receive the subscribed events, the channel is closed
+// after the subscription is closed
+func (es *EventSubscription) Events() <-chan Event {
+	return es.eventChan
+}
+
+// Close stops receiving the events
+func (es *EventSubscription) Close() {
+	eventSubscriptions.lock.Lock()
+	defer eventSubscriptions.lock.Unlock()
+	if eventSubscriptions.subscriptions[es] {
+		delete(eventSubscriptions.subscriptions, es)
+		close(es.eventChan)
+	}
+}
+
+func (em *eventSubscriptionManager) dispatch(event Event) {
+	em.lock.Lock()
+	defer em.lock.Unlock()
+	for subscription := range em.subscriptions {
+		if !subscription.events[event.GetType()] {
+			continue
+		}
+		select {
+		case subscription.eventChan <- event:
+		default:
+			log.WithFields(log.Fields{"event": event.GetType()}).Warn("event subscription is full, discard the event")
+		}
+	}
+}
+
+// the header fields with integer value
+var integerEventFields = map[string]bool{"pid": true, "tries": true, "expected": true, "when": true}
+
+// Fields returns the serial, type and all the fields in the event body. The body
+// header "key1:value1 key2:value2" is split to fields and the data after the header
+// is returned in field "data"
+func Fields(event Event) map[string]interface{} {
+	fields := map[string]interface{}{
+		"serial": event.GetSerial(),
+		"type":   event.GetType(),
+	}
+	header, data, hasData := strings.Cut(event.GetBody(), "\n")
+	for _, token := range strings.Fields(header) {
+		key, value, ok := strings.Cut(token, ":")
+		if !ok {
+			continue
+		}
+		if integerEventFields[key] {
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				fields[key] = n
+				continue
+			}
+		}
+		fields[key] = value
+	}
+	if hasData {
+		fields["data"] = data
+	}
+	return fields
+}
@@ -327,6 +327,18 @@ func (em *EventListenerManager) registerEventListener(eventListenerName string,
 	listener *EventListener,
 ) {
 	em.namedListeners[eventListenerName] = listener
+	allEvents := expandEventTypes(events)
+	for event := range allEvents {
+		log.WithFields(log.Fields{"eventListener": eventListenerName, "event": event}).Info("register event listener")
+		if _, ok := em.eventListeners[event]; !ok {
+			em.eventListeners[event] = make(map[*EventListener]bool)
+		}
+		em.eventListeners[event][listener] = true
+	}
+}
+
+// get all the final events of the events, an abstract event is expanded to all its derived events
+func expandEventTypes(events []string) map[string]bool {
 	allEvents := make(map[string]bool)
 	for _, event := range events {
 		for k, values := range eventTypeDerives {
@@ -341,13 +353,7 @@ func (em *EventListenerManager) registerEventListener(eventListenerName string,
 			}
 		}
 	}
-	for event := range allEvents {
-		log.WithFields(log.Fields{"eventListener": eventListenerName, "event": event}).Info("register event listener")
-		if _, ok := em.eventListeners[event]; !ok {
-			em.eventListeners[event] = make(map[*EventListener]bool)
-		}
-		em.eventListeners[event][listener] = true
-	}
+	return allEvents
 }
 
 // RegisterEventListener registers event listener to accept the emitted events
@@ -373,6 +379,21 @@ func (em *EventListenerManager) EmitEvent(event Event) {
 // RemoteCommunicationEvent remote communication event definition
 type RemoteCommunicationEvent struct {
 	BaseEvent
+	typ  string
+	data string
+}
+
+// NewRemoteCommunicationEvent creates new RemoteCommunicationEvent object
+func NewRemoteCommunicationEvent(typ string, data string) *RemoteCommunicationEvent {
+	r := &RemoteCommunicationEvent{typ: typ, data: data}
+	r.eventType = "REMOTE_COMMUNICATION"
+	r.serial = nextEventSerial()
+	return r
+}
+
+// GetBody returns body of remote communication event
+func (r *RemoteCommunicationEvent) GetBody() string {
+	return fmt.Sprintf("type:%s\n%s", r.typ, r.data)
 }
 
 // ProcCommEvent process communication event definition
@@ -408,6 +429,7 @@ func (p *ProcCommEvent) GetBody() string {
 // EmitEvent emits event to default event listener manager
 func EmitEvent(event Event) {
 	eventListenerManager.EmitEvent(event)
+	eventSubscriptions.dispatch(event)
 }
 
 // TickEvent the tick event definition
@@ -540,6 +562,8 @@ type ProcessStateEvent struct {
 	tries       int
 	expected    int
 	pid         int
+	// the delay before the next start attempt, only for PROCESS_STATE_BACKOFF
+	delay time.Duration
 }
 
 // CreateProcessStartingEvent emits create process starting event
@@ -585,6 +609,7 @@ func CreateProcessBackoffEvent(process string,
 	group string,
 	fromState string,
 	tries int,
+	delay time.Duration,
 ) *ProcessStateEvent {
 	r := &ProcessStateEvent{
 		processName: process,
@@ -593,6 +618,7 @@ func CreateProcessBackoffEvent(process string,
 		tries:       tries,
 		expected:    -1,
 		pid:         0,
+		delay:       delay,
 	}
 	r.eventType = "PROCESS_STATE_BACKOFF"
 	r.serial = nextEventSerial()
@@ -707,6 +733,10 @@ func (pse *ProcessStateEvent) GetBody() string {
 	if pse.pid != 0 {
 		body = fmt.Sprintf("%s pid:%d", body, pse.pid)
 	}
+
+	if pse.delay > 0 {
+		body = fmt.Sprintf("%s delay:%.3f", body, pse.delay.Seconds())
+	}
 	return body
 }
 
@@ -777,4 +807,26 @@ func CreateProcessLogStderrEvent(processName string,
 // ProcessGroupEvent the process group event definition
 type ProcessGroupEvent struct {
 	BaseEvent
+	groupName string
+}
+
+// NewProcessGroupAddedEvent creates the PROCESS_GROUP_ADDED event
+func NewProcessGroupAddedEvent(group string) *ProcessGroupEvent {
+	r := &ProcessGroupEvent{groupName: group}
+	r.eventType = "PROCESS_GROUP_ADDED"
+	r.serial = nextEventSerial()
+	return r
+}
+
+// NewProcessGroupRemovedEvent creates the PROCESS_GROUP_REMOVED event
+func NewProcessGroupRemovedEvent(group string) *ProcessGroupEvent {
+	r := &ProcessGroupEvent{groupName: group}
+	r.eventType = "PROCESS_GROUP_REMOVED"
+	r.serial = nextEventSerial()
+	return r
+}
+
+// GetBody returns body of process group event
+func (r *ProcessGroupEvent) GetBody() string {
+	return fmt.Sprintf("groupname:%s\n", r.groupName)
 }
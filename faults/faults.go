@@ -59,6 +59,9 @@ const (
 )
 
 // NewFault creates Fault object as xml rpc result
+//
+// The fault is returned by value because the xml rpc codec only recognizes
+// xmlrpc.Fault values and reports anything else as a generic application error
 func NewFault(code int, desc string) error {
-	return &xmlrpc.Fault{Code: code, String: desc}
+	return xmlrpc.Fault{Code: code, String: desc}
 }
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/ochinchina/supervisord/faults"
+	"github.com/ochinchina/supervisord/logger"
+	log "github.com/sirupsen/logrus"
+)
+
+// logStreamMessage the message pushed to the client for new log data
+type logStreamMessage struct {
+	Data string `json:"data"`
+	// the offset in current log file after the data, it can be used to resume the streaming
+	Offset int64 `json:"offset"`
+	// the log file is rotated or cleared, the offset restarts from 0
+	Rotated bool `json:"rotated,omitempty"`
+}
+
+var logStreamUpgrader = websocket.Upgrader{}
+
+// create the log follower for the program log stream in the request.
+//
+// The streaming starts from the "offset" query parameter, or the "Last-Event-ID" header if
+// the EventSource reconnects, otherwise the last defaultTailLogLength bytes are streamed
+func (sr *SupervisorRestful) createLogFollower(w http.ResponseWriter, req *http.Request) (*logger.LogFollower, bool) {
+	params := mux.Vars(req)
+	offset := int64(-defaultTailLogLength)
+	offsetValue := req.URL.Query().Get("offset")
+	if offsetValue == "" {
+		offsetValue = req.Header.Get("Last-Event-ID")
+	}
+	if offsetValue != "" {
+		n, err := strconv.ParseInt(offsetValue, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid offset: %s", offsetValue))
+			return nil, false
+		}
+		offset = n
+	}
+
+	procLogger, err := sr.supervisor.getProcessLogger(params["name"], params["stream"] == "stderr")
+	if err != nil {
+		writeFault(w, err)
+		return nil, false
+	}
+	logFile := logger.GetLogFile(procLogger)
+	if logFile == "" {
+		writeFault(w, faults.NewFault(faults.NoFile, "NO_FILE"))
+		return nil, false
+	}
+	follower, err := logger.NewLogFollower(logFile, offset)
+	if err != nil {
+		writeFault(w, faults.NewFault(faults.Failed, err.Error()))
+		return nil, false
+	}
+	return follower, true
+}
+
+// StreamLog pushes the program stdout or stderr log to the client with server-sent events.
+// Every event has the offset as its id and the logStreamMessage in json as its data
+func (sr *SupervisorRestful) StreamLog(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+	follower, ok := sr.createLogFollower(w, req)
+	if !ok {
+		return
+	}
+	defer follower.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		chunk, err := follower.Next(req.Context())
+		if err != nil {
+			return
+		}
+		b, _ := json.Marshal(logStreamMessage{Data: chunk.Data, Offset: chunk.Offset, Rotated: chunk.Rotated})
+		if _, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", chunk.Offset, b); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// StreamLogWebSocket pushes the program stdout or stderr log to the client over WebSocket.
+// Every text message is a logStreamMessage in json
+func (sr *SupervisorRestful) StreamLogWebSocket(w http.ResponseWriter, req *http.Request) {
+	follower, ok := sr.createLogFollower(w, req)
+	if !ok {
+		return
+	}
+	defer follower.Close()
+
+	conn, err := logStreamUpgrader.Upgrade(w, req, nil)
+	if err != nil {
+		log.WithFields(log.Fields{log.ErrorKey: err}).Error("fail to upgrade to websocket")
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+	// read the messages from client to process the control messages and detect the closing
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		chunk, err := follower.Next(ctx)
+		if err != nil {
+			return
+		}
+		if err = conn.WriteJSON(logStreamMessage{Data: chunk.Data, Offset: chunk.Offset, Rotated: chunk.Rotated}); err != nil {
+			return
+		}
+	}
+}
@@ -118,6 +118,7 @@ func (l *FileLogger) backupFiles() {
 func (l *FileLogger) ClearCurLogFile() error {
 	l.locker.Lock()
 	defer l.locker.Unlock()
+	defer notifyLogFileWritten(l.name)
 
 	return l.openFile(true)
 }
@@ -126,6 +127,7 @@ func (l *FileLogger) ClearCurLogFile() error {
 func (l *FileLogger) ClearAllLogFile() error {
 	l.locker.Lock()
 	defer l.locker.Unlock()
+	defer notifyLogFileWritten(l.name)
 
 	for i := l.backups; i > 0; i-- {
 		logFile := fmt.Sprintf("%s.%d", l.name, i)
@@ -271,6 +273,7 @@ func (l *FileLogger) Write(p []byte) (int, error) {
 		l.backupFiles()
 		l.openFile(true)
 	}
+	notifyLogFileWritten(l.name)
 	return n, err
 }
 
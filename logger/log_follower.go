@@ -0,0 +1,232 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// the interval to check the log file if no write notification is received
+const followPollingInterval = time.Second
+
+// the max size of log data returned by one LogFollower.Next() call
+const followMaxChunkSize = 64 * 1024
+
+// the watchers of log files, the watchers are notified when the log file is written by FileLogger
+var logFileWatchers = struct {
+	sync.Mutex
+	watchers map[string]map[chan struct{}]bool
+}{watchers: make(map[string]map[chan struct{}]bool)}
+
+func watchLogFile(name string) chan struct{} {
+	ch := make(chan struct{}, 1)
+	logFileWatchers.Lock()
+	defer logFileWatchers.Unlock()
+	if _, ok := logFileWatchers.watchers[name]; !ok {
+		logFileWatchers.watchers[name] = make(map[chan struct{}]bool)
+	}
+	logFileWatchers.watchers[name][ch] = true
+	return ch
+}
+
+func unwatchLogFile(name string, ch chan struct{}) {
+	logFileWatchers.Lock()
+	defer logFileWatchers.Unlock()
+	delete(logFileWatchers.watchers[name], ch)
+	if len(logFileWatchers.watchers[name]) == 0 {
+		delete(logFileWatchers.watchers, name)
+	}
+}
+
+// notify all the watchers of the log file without blocking
+func notifyLogFileWritten(name string) {
+	logFileWatchers.Lock()
+	defer logFileWatchers.Unlock()
+	for ch := range logFileWatchers.watchers[name] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// GetLogFile returns the file name of the logger writes to, empty string is returned
+// if the logger does not write to a file
+func GetLogFile(logger Logger) string {
+	switch l := logger.(type) {
+	case *FileLogger:
+		return l.name
+	case *CompositeLogger:
+		if len(l.loggers) > 0 {
+			return GetLogFile(l.loggers[0])
+		}
+	case *LogCaptureLogger:
+		return GetLogFile(l.underlineLogger)
+	}
+	return ""
+}
+
+// LogChunk the log data read by LogFollower
+type LogChunk struct {
+	// the log data
+	Data string
+	// the offset in the current log file after the data
+	Offset int64
+	// true if the log file is rotated or cleared, the Offset is restarted from the new log file
+	Rotated bool
+}
+
+// LogFollower follows a log file written by FileLogger like "tail -F", the log file
+// rotation by backupFiles() and truncation are handled without losing or duplicating data
+type LogFollower struct {
+	name    string
+	file    *os.File
+	offset  int64
+	watchCh chan struct{}
+}
+
+// NewLogFollower creates LogFollower object to follow the log file from offset.
+//
+// A negative offset means the position from the end of the log file. If the offset exceeds
+// the size of the log file and the first backup file is large enough, the log file is
+// considered rotated since the offset was got and the follower resumes from the backup file
+func NewLogFollower(name string, offset int64) (*LogFollower, error) {
+	f := &LogFollower{name: name, watchCh: watchLogFile(name)}
+	file, err := os.Open(name)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			f.Close()
+			return nil, err
+		}
+		// the log file will be created when the program is started
+		return f, nil
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		f.Close()
+		return nil, err
+	}
+	size := fileInfo.Size()
+	if offset < 0 {
+		offset += size
+		if offset < 0 {
+			offset = 0
+		}
+	} else if offset > size {
+		backupFile := fmt.Sprintf("%s.1", name)
+		if backupInfo, err := os.Stat(backupFile); err == nil && backupInfo.Size() >= offset {
+			if backup, err := os.Open(backupFile); err == nil {
+				file.Close()
+				file = backup
+			}
+		} else {
+			offset = size
+		}
+	}
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		f.Close()
+		return nil, err
+	}
+	f.file = file
+	f.offset = offset
+	return f, nil
+}
+
+// Offset returns the offset in the current log file
+func (f *LogFollower) Offset() int64 {
+	return f.offset
+}
+
+// Next waits until new log data is available and returns it, or the ctx is done
+func (f *LogFollower) Next(ctx context.Context) (LogChunk, error) {
+	ticker := time.NewTicker(followPollingInterval)
+	defer ticker.Stop()
+	for {
+		chunk, err := f.read()
+		if err != nil || chunk.Data != "" || chunk.Rotated {
+			return chunk, err
+		}
+		select {
+		case <-ctx.Done():
+			return chunk, ctx.Err()
+		case <-f.watchCh:
+		case <-ticker.C:
+		}
+	}
+}
+
+// read the available log data and switch to the new log file if the log file is rotated
+func (f *LogFollower) read() (LogChunk, error) {
+	if f.file == nil {
+		file, err := os.Open(f.name)
+		if err != nil {
+			return LogChunk{Offset: f.offset}, nil
+		}
+		f.file = file
+		f.offset = 0
+	}
+
+	data, err := f.readAvailable()
+	if err != nil || len(data) > 0 {
+		return LogChunk{Data: data, Offset: f.offset}, err
+	}
+
+	openedInfo, err := f.file.Stat()
+	if err != nil {
+		return LogChunk{Offset: f.offset}, err
+	}
+	curInfo, err := os.Stat(f.name)
+	if err != nil {
+		// the log file is being rotated, check it later
+		return LogChunk{Offset: f.offset}, nil
+	}
+	if !os.SameFile(openedInfo, curInfo) {
+		// the opened file is renamed to backup file. All the data is written to the
+		// backup file before renaming so read it to the end before switching
+		data, err = f.readAvailable()
+		if err != nil || len(data) > 0 {
+			return LogChunk{Data: data, Offset: f.offset}, err
+		}
+		file, err := os.Open(f.name)
+		if err != nil {
+			return LogChunk{Offset: f.offset}, nil
+		}
+		f.file.Close()
+		f.file = file
+		f.offset = 0
+		return LogChunk{Offset: 0, Rotated: true}, nil
+	}
+	if curInfo.Size() < f.offset {
+		// the log file is truncated
+		if _, err = f.file.Seek(0, io.SeekStart); err != nil {
+			return LogChunk{Offset: f.offset}, err
+		}
+		f.offset = 0
+		return LogChunk{Offset: 0, Rotated: true}, nil
+	}
+	return LogChunk{Offset: f.offset}, nil
+}
+
+func (f *LogFollower) readAvailable() (string, error) {
+	b := make([]byte, followMaxChunkSize)
+	n, err := f.file.Read(b)
+	f.offset += int64(n)
+	if err == io.EOF {
+		err = nil
+	}
+	return string(b[:n]), err
+}
+
+// Close stops following the log file
+func (f *LogFollower) Close() {
+	unwatchLogFile(f.name, f.watchCh)
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
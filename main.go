@@ -13,7 +13,6 @@ import (
 	"github.com/jessevdk/go-flags"
 	log "github.com/sirupsen/logrus"
 
-	"github.com/ochinchina/go-ini"
 	"github.com/ochinchina/supervisord/config"
 	"github.com/ochinchina/supervisord/logger"
 )
@@ -31,7 +30,7 @@ func initSignals(s *Supervisor) {
 	go func() {
 		sig := <-sigs
 		log.WithFields(log.Fields{"signal": sig}).Info("receive a signal to stop all process & exit")
-		s.procMgr.StopAllProcesses()
+		s.stopAllProcesses()
 		os.Exit(-1)
 	}()
 }
@@ -93,6 +92,8 @@ func findSupervisordConf() (string, error) {
 	possibleSupervisordConf := []string{
 		options.Configuration,
 		"./supervisord.ini",
+		"./supervisord.yaml",
+		"./supervisord.toml",
 		"./etc/supervisord.conf",
 		"/etc/supervisord.conf",
 		"/etc/supervisor/supervisord.conf",
@@ -123,7 +124,8 @@ func runServer() {
 		s := NewSupervisor(options.Configuration)
 		initSignals(s)
 		if sErr := s.Reload(true); sErr != nil {
-			panic(sErr)
+			fmt.Fprintf(os.Stderr, "fail to load the configuration %s: %v\n", options.Configuration, sErr)
+			os.Exit(1)
 		}
 		s.WaitForExit()
 	}
@@ -133,8 +135,7 @@ func runServer() {
 func getSupervisordLogFile(configFile string) string {
 	configFileDir := filepath.Dir(configFile)
 	env := config.NewStringExpression("here", configFileDir)
-	myini := ini.NewIni()
-	myini.LoadFile(configFile)
+	myini := config.LoadIniFile(configFile)
 	cwd, err := os.Getwd()
 	if err != nil {
 		cwd = "."
@@ -201,6 +202,72 @@ func main() {
 		os.Exit(0)
 	}
 
+	if _, cmdErr := parser.AddCommand("check",
+		"check the configuration",
+		"The check subcommand validates the configuration file and its included files, the problems are reported with their files and lines",
+		&checkCommand); cmdErr != nil {
+		_, _ = fmt.Fprintln(os.Stdout, cmdErr)
+		os.Exit(0)
+	}
+
+	configCmd, cmdErr := parser.AddCommand("config",
+		"inspect the configuration",
+		"The config subcommand inspects the configuration files",
+		&configCommand)
+	if cmdErr != nil {
+		_, _ = fmt.Fprintln(os.Stdout, cmdErr)
+		os.Exit(0)
+	}
+	if _, cmdErr := configCmd.AddCommand("dump",
+		"show the effective configuration of programs",
+		"dump [-f ini|json] [<program>...] shows every key of the programs with its final value and where it comes from",
+		&configDumpCommand); cmdErr != nil {
+		_, _ = fmt.Fprintln(os.Stdout, cmdErr)
+		os.Exit(0)
+	}
+	if _, cmdErr := configCmd.AddCommand("convert",
+		"convert the ini configuration to YAML or TOML",
+		"convert [-f yaml|toml] [<file>] prints the ini configuration file in YAML or TOML, the included files are not converted",
+		&configConvertCommand); cmdErr != nil {
+		_, _ = fmt.Fprintln(os.Stdout, cmdErr)
+		os.Exit(0)
+	}
+
+	ctlCmd, cmdErr := parser.AddCommand("ctl",
+		"control a running supervisord",
+		"The ctl subcommand controls a running supervisord through its XML-RPC interface",
+		&ctlCommand)
+	if cmdErr != nil {
+		_, _ = fmt.Fprintln(os.Stdout, cmdErr)
+		os.Exit(0)
+	}
+	ctlSubCommands := []struct {
+		name, shortDescription, longDescription string
+		data                                    interface{}
+	}{
+		{"status", "show the status of processes", "status [<name>|<group>:*|all]...", &statusCommand},
+		{"start", "start processes", "start <name>|<group>:*|all...", &startCommand},
+		{"stop", "stop processes", "stop <name>|<group>:*|all...", &stopCommand},
+		{"restart", "restart processes", "restart <name>|<group>:*|all...", &restartCommand},
+		{"signal", "send a signal to processes", "signal <signal> <name>|<group>:*|all...", &signalCommand},
+		{"reset-breaker", "reset the restart limit of processes", "reset-breaker <name>|<group>:*...", &resetBreakerCommand},
+		{"tail", "show the tail of process log", "tail [-f] [-n <bytes>] <name> [stdout|stderr]", &tailCommand},
+		{"group", "start, stop or restart groups in dependency order", "group [-t <seconds>] start|stop|restart <group>...", &groupCommand},
+		{"scale", "change the number of instances of a program", "scale [-p] <program> <numprocs>", &scaleCommand},
+		{"rolling-restart", "restart the instances of a program one batch at a time", "rolling-restart [-b batch] [-t timeout] <program>", &rollingRestartCommand},
+		{"add-program", "add a program without editing the configuration files", "add-program [-p] <program> command=<command> [key=value...]", &addProgramCommand},
+		{"remove-program", "stop and remove programs", "remove-program <program>...", &removeProgramCommand},
+		{"reload", "reload the configuration", "reload the configuration and apply the added, changed and removed programs", &reloadCommand},
+		{"shutdown", "shut down supervisord", "stop all the processes and shut down supervisord", &shutdownCommand},
+		{"pid", "show the pid of supervisord or processes", "pid [<name>|<group>:*|all]...", &pidCommand},
+	}
+	for _, sub := range ctlSubCommands {
+		if _, cmdErr := ctlCmd.AddCommand(sub.name, sub.shortDescription, sub.longDescription, sub.data); cmdErr != nil {
+			_, _ = fmt.Fprintln(os.Stdout, cmdErr)
+			os.Exit(0)
+		}
+	}
+
 	if _, err := parser.Parse(); err != nil {
 		flagsErr, ok := err.(*flags.Error)
 		if ok {
@@ -213,5 +280,7 @@ func main() {
 				os.Exit(1)
 			}
 		}
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
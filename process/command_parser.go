@@ -6,6 +6,8 @@ import (
 	"os/exec"
 	"syscall"
 	"unicode"
+
+	"github.com/ochinchina/supervisord/config"
 )
 
 // find the position of byte ch in the string s start from offset
@@ -117,3 +119,20 @@ func executeCommand(command interface{}) ([]byte, error) {
 	}
 	return cmd.CombinedOutput()
 }
+
+// ParseCommand splits the command line to the program and its arguments
+func ParseCommand(command string) ([]string, error) {
+	return parseCommand(command)
+}
+
+// GetCommandArgs returns the program and the arguments of the command of the program, the argv
+// array declared by command[0], command[1]... is used as it is without parsing
+func GetCommandArgs(entry *config.Entry) ([]string, error) {
+	if args := entry.GetArgs("command"); args != nil {
+		if args[0] == "" {
+			return nil, fmt.Errorf("empty program in command[0]")
+		}
+		return args, nil
+	}
+	return parseCommand(entry.GetStringExpression("command", ""))
+}
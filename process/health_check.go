@@ -0,0 +1,215 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// HealthStatus the result of the health check of the process
+type HealthStatus int
+
+const (
+	// HealthNone no health check is configured
+	HealthNone HealthStatus = iota
+	// HealthStarting the health check is not passed yet
+	HealthStarting
+	// HealthHealthy the last health check is passed
+	HealthHealthy
+	// HealthUnhealthy the health check fails "healthcheck_retries" times in a row
+	HealthUnhealthy
+)
+
+// String convert HealthStatus to human-readable string
+func (h HealthStatus) String() string {
+	switch h {
+	case HealthStarting:
+		return "starting"
+	case HealthHealthy:
+		return "healthy"
+	case HealthUnhealthy:
+		return "unhealthy"
+	default:
+		return "none"
+	}
+}
+
+// healthChecker checks the health of a running process periodically. The check is one of:
+//
+//	healthcheck_command - a command exits with 0
+//	healthcheck_http - a http GET returns healthcheck_http_status, default is any 2xx status
+//	healthcheck_tcp - a tcp connection to the address can be established
+type healthChecker struct {
+	proc        *Process
+	check       func(ctx context.Context) error
+	interval    time.Duration
+	timeout     time.Duration
+	retries     int
+	startPeriod time.Duration
+	restart     bool
+}
+
+// create the health checker from the program configuration, nil is returned if no health
+// check is configured
+func newHealthChecker(p *Process) *healthChecker {
+	hc := &healthChecker{
+		proc:        p,
+		interval:    time.Duration(p.config.GetInt("healthcheck_interval", 10)) * time.Second,
+		timeout:     time.Duration(p.config.GetInt("healthcheck_timeout", 5)) * time.Second,
+		retries:     p.config.GetInt("healthcheck_retries", 3),
+		startPeriod: time.Duration(p.config.GetInt("healthcheck_start_period", 0)) * time.Second,
+		restart:     p.config.GetBool("healthcheck_restart", false),
+	}
+	if command := p.config.GetStringExpression("healthcheck_command", ""); command != "" {
+		hc.check = func(ctx context.Context) error {
+			return p.runHealthCheckCommand(ctx, command)
+		}
+	} else if url := p.config.GetStringExpression("healthcheck_http", ""); url != "" {
+		expectedStatus := p.config.GetInt("healthcheck_http_status", 0)
+		hc.check = func(ctx context.Context) error {
+			return checkHTTPHealth(ctx, url, expectedStatus)
+		}
+	} else if address := p.config.GetStringExpression("healthcheck_tcp", ""); address != "" {
+		hc.check = func(ctx context.Context) error {
+			return checkTCPHealth(ctx, address)
+		}
+	} else {
+		return nil
+	}
+	if hc.interval <= 0 {
+		hc.interval = 10 * time.Second
+	}
+	if hc.timeout <= 0 {
+		hc.timeout = 5 * time.Second
+	}
+	if hc.retries <= 0 {
+		hc.retries = 1
+	}
+	return hc
+}
+
+func (p *Process) runHealthCheckCommand(ctx context.Context, command string) error {
+	args, err := parseCommand(command)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("empty health check command")
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = p.getEnv()
+	cmd.Dir = p.config.GetStringExpression("directory", "")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, out)
+	}
+	return nil
+}
+
+func checkHTTPHealth(ctx context.Context, url string, expectedStatus int) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if expectedStatus > 0 && resp.StatusCode != expectedStatus {
+		return fmt.Errorf("http status %d, expect %d", resp.StatusCode, expectedStatus)
+	}
+	if expectedStatus <= 0 && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		return fmt.Errorf("http status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func checkTCPHealth(ctx context.Context, address string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// run the health check until ctx is cancelled. The failures in the start period are not
+// counted, the process becomes unhealthy after "retries" failures in a row
+func (hc *healthChecker) run(ctx context.Context) {
+	startTime := time.Now()
+	failures := 0
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, hc.timeout)
+		err := hc.check(checkCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			failures = 0
+			hc.proc.setHealth(ctx, HealthHealthy)
+		} else if time.Since(startTime) >= hc.startPeriod || hc.proc.GetHealth() == HealthHealthy {
+			failures++
+			log.WithFields(log.Fields{"program": hc.proc.GetName(), "failures": failures, log.ErrorKey: err}).Warn("health check fails")
+			if failures >= hc.retries && hc.proc.setHealth(ctx, HealthUnhealthy) && hc.restart {
+				log.WithFields(log.Fields{"program": hc.proc.GetName()}).Warn("restart the unhealthy program")
+				go hc.proc.Restart(false)
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(hc.interval):
+		}
+	}
+}
+
+// set the health status if the health check is not cancelled, returns true if the status is changed
+func (p *Process) setHealth(ctx context.Context, health HealthStatus) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if ctx.Err() != nil || p.health == health {
+		return false
+	}
+	log.WithFields(log.Fields{"program": p.GetName(), "health": health.String()}).Info("health status is changed")
+	p.health = health
+	return true
+}
+
+// GetHealth returns the health status of the process
+func (p *Process) GetHealth() HealthStatus {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.health
+}
+
+// start the health check when the process enters Running state, it must be called with the lock
+func (p *Process) startHealthCheck() {
+	p.stopHealthCheck()
+	hc := newHealthChecker(p)
+	if hc == nil {
+		p.health = HealthNone
+		return
+	}
+	p.health = HealthStarting
+	var ctx context.Context
+	ctx, p.healthCheckCancel = context.WithCancel(context.Background())
+	go hc.run(ctx)
+}
+
+// stop the health check when the process leaves Running state, it must be called with the lock
+func (p *Process) stopHealthCheck() {
+	if p.healthCheckCancel != nil {
+		p.healthCheckCancel()
+		p.healthCheckCancel = nil
+	}
+	p.health = HealthNone
+}
@@ -11,6 +11,8 @@ type procCollector struct {
 	stateDesc      *prometheus.Desc
 	exitStatusDesc *prometheus.Desc
 	startTimeDesc  *prometheus.Desc
+	healthyDesc    *prometheus.Desc
+	numprocsDesc   *prometheus.Desc
 	procMgr        *Manager
 }
 
@@ -46,6 +48,18 @@ func NewProcCollector(mgr *Manager) *procCollector {
 			labelNames,
 			nil,
 		),
+		healthyDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "healthy"),
+			"Process passes its health check",
+			labelNames,
+			nil,
+		),
+		numprocsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "numprocs"),
+			"Number of instances of the program",
+			labelNames,
+			nil,
+		),
 		procMgr: mgr,
 	}
 }
@@ -56,6 +70,8 @@ func (c *procCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.stateDesc
 	ch <- c.exitStatusDesc
 	ch <- c.startTimeDesc
+	ch <- c.healthyDesc
+	ch <- c.numprocsDesc
 }
 
 // Collect gathers prometheus metrics for all supervised processes
@@ -70,6 +86,7 @@ func (c *procCollector) collectProcessMetrics(proc *Process, ch chan<- prometheu
 
 	ch <- prometheus.MustNewConstMetric(c.stateDesc, prometheus.GaugeValue, float64(proc.GetState()), labels...)
 	ch <- prometheus.MustNewConstMetric(c.exitStatusDesc, prometheus.GaugeValue, float64(proc.GetExitstatus()), labels...)
+	ch <- prometheus.MustNewConstMetric(c.numprocsDesc, prometheus.GaugeValue, float64(proc.config.GetInt("numprocs", 1)), labels...)
 
 	if proc.isRunning() {
 		ch <- prometheus.MustNewConstMetric(c.upDesc, prometheus.GaugeValue, 1, labels...)
@@ -77,4 +94,12 @@ func (c *procCollector) collectProcessMetrics(proc *Process, ch chan<- prometheu
 	} else {
 		ch <- prometheus.MustNewConstMetric(c.upDesc, prometheus.GaugeValue, 0, labels...)
 	}
+
+	if health := proc.GetHealth(); health != HealthNone {
+		healthy := 0.0
+		if health == HealthHealthy {
+			healthy = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.healthyDesc, prometheus.GaugeValue, healthy, labels...)
+	}
 }
@@ -1,6 +1,7 @@
 package process
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -30,6 +31,9 @@ const (
 	// Stopped the stopped state
 	Stopped State = iota
 
+	// Waiting the waiting state, the program waits for its depends_on programs to be ready
+	Waiting = 5
+
 	// Starting the starting state
 	Starting = 10
 
@@ -64,6 +68,8 @@ func (p State) String() string {
 	switch p {
 	case Stopped:
 		return "Stopped"
+	case Waiting:
+		return "Waiting"
 	case Starting:
 		return "Starting"
 	case Running:
@@ -94,10 +100,26 @@ type Process struct {
 	// true if the process is stopped by user
 	stopByUser bool
 	retryTimes *int32
-	lock       sync.RWMutex
-	stdin      io.WriteCloser
-	StdoutLog  logger.Logger
-	StderrLog  logger.Logger
+	// the reason why the process is waiting or fails to start
+	spawnErr string
+	// the last STATUS= sent by the program to the notify socket
+	notifyStatus string
+	// the restart backoff: the number of delayed restarts, the current delay and the next attempt time
+	backoffAttempts int
+	backoffDelay    time.Duration
+	nextAttempt     time.Time
+	// the times of the automatic restarts in the restart_limit_window and if the limit is exceeded
+	restartHistory []time.Time
+	breakerTripped bool
+	// the manager to find the depends_on programs
+	procMgr *Manager
+	// the health status and the function to stop the health check
+	health            HealthStatus
+	healthCheckCancel context.CancelFunc
+	lock              sync.RWMutex
+	stdin             io.WriteCloser
+	StdoutLog         logger.Logger
+	StderrLog         logger.Logger
 }
 
 // NewProcess creates new Process object
@@ -153,6 +175,7 @@ func (p *Process) Start(wait bool) {
 
 	p.inStart = true
 	p.stopByUser = false
+	p.backoffAttempts = 0
 	p.lock.Unlock()
 
 	var runCond *sync.Cond
@@ -161,19 +184,20 @@ func (p *Process) Start(wait bool) {
 		runCond.L.Lock()
 	}
 
+	finishCb := func() {
+		if wait {
+			runCond.L.Lock()
+			runCond.Signal()
+			runCond.L.Unlock()
+		}
+	}
 	go func() {
 		for {
-			p.run(func() {
-				if wait {
-					runCond.L.Lock()
-					runCond.Signal()
-					runCond.L.Unlock()
-				}
-			})
-			// avoid print too many logs if fail to start program too quickly
-			if time.Now().Unix()-p.startTime.Unix() < 2 {
-				time.Sleep(5 * time.Second)
+			if !p.waitForDependencies() {
+				finishCb()
+				break
 			}
+			p.run(finishCb)
 			if p.stopByUser {
 				log.WithFields(log.Fields{"program": p.GetName()}).Info("Stopped by user, don't start it again")
 				break
@@ -182,6 +206,20 @@ func (p *Process) Start(wait bool) {
 				log.WithFields(log.Fields{"program": p.GetName()}).Info("Don't start the stopped program because its autorestart flag is false")
 				break
 			}
+			if p.checkRestartLimit() {
+				break
+			}
+			// avoid print too many logs if fail to start program too quickly
+			p.lock.Lock()
+			legacyDelay := time.Duration(0)
+			if time.Since(p.startTime) < 2*time.Second {
+				legacyDelay = 5 * time.Second
+			}
+			delay := p.computeRestartDelay(time.Since(p.startTime), legacyDelay)
+			p.lock.Unlock()
+			if delay > 0 {
+				p.sleepBeforeRestart(delay)
+			}
 		}
 		p.lock.Lock()
 		p.inStart = false
@@ -219,16 +257,29 @@ func (p *Process) GetDescription() string {
 		minutes := seconds / 60
 		hours := minutes / 60
 		days := hours / 24
+		description := fmt.Sprintf("pid %d, uptime %d:%02d:%02d", p.cmd.Process.Pid, hours%24, minutes%60, seconds%60)
 		if days > 0 {
-			return fmt.Sprintf("pid %d, uptime %d days, %d:%02d:%02d", p.cmd.Process.Pid, days, hours%24, minutes%60, seconds%60)
+			description = fmt.Sprintf("pid %d, uptime %d days, %d:%02d:%02d", p.cmd.Process.Pid, days, hours%24, minutes%60, seconds%60)
 		}
-		return fmt.Sprintf("pid %d, uptime %d:%02d:%02d", p.cmd.Process.Pid, hours%24, minutes%60, seconds%60)
+		if p.notifyStatus != "" {
+			description = fmt.Sprintf("%s, status %s", description, p.notifyStatus)
+		}
+		return description
+	} else if p.spawnErr != "" && (p.state == Waiting || p.state == Fatal) {
+		return p.spawnErr
 	} else if p.state != Stopped {
 		return p.stopTime.String()
 	}
 	return ""
 }
 
+// GetSpawnErr returns the reason why the process is waiting or fails to start
+func (p *Process) GetSpawnErr() string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.spawnErr
+}
+
 // GetExitstatus returns exit status of the process if the program exit
 func (p *Process) GetExitstatus() int {
 	p.lock.RLock()
@@ -251,7 +302,7 @@ func (p *Process) GetPid() int {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
 
-	if p.state == Stopped || p.state == Fatal || p.state == Unknown || p.state == Exited || p.state == Backoff {
+	if p.state == Stopped || p.state == Waiting || p.state == Fatal || p.state == Unknown || p.state == Exited || p.state == Backoff {
 		return 0
 	}
 	return p.cmd.Process.Pid
@@ -381,7 +432,7 @@ func (p *Process) getExitCodes() []int {
 	strExitCodes := strings.Split(p.config.GetString("exitcodes", "0,2"), ",")
 	result := make([]int, 0)
 	for _, val := range strExitCodes {
-		i, err := strconv.Atoi(val)
+		i, err := strconv.Atoi(strings.TrimSpace(val))
 		if err == nil {
 			result = append(result, i)
 		}
@@ -399,7 +450,7 @@ func (p *Process) isRunning() bool {
 
 // create Command object for the program
 func (p *Process) createProgramCommand() error {
-	args, err := parseCommand(p.config.GetStringExpression("command", ""))
+	args, err := GetCommandArgs(p.config)
 	if err != nil {
 		return err
 	}
@@ -440,6 +491,8 @@ func (p *Process) setProgramRestartChangeMonitor(programPath string) {
 				}
 			} else if len(s) > 0 {
 				p.sendSignals(strings.Fields(s), true)
+			} else if p.config.GetInt("numprocs", 1) > 1 && p.procMgr != nil && p.procMgr.rollingRestart(p) {
+				log.WithFields(log.Fields{"program": p.GetName()}).Info("restart the instances of the program one by one")
 			} else {
 				p.Stop(true)
 				p.Start(true)
@@ -492,14 +545,19 @@ func (p *Process) waitForExit(startSecs int64) {
 // fail to start the program
 func (p *Process) failToStartProgram(reason string, finishCb func()) {
 	log.WithFields(log.Fields{"program": p.GetName()}).Errorf(reason)
+	p.spawnErr = reason
 	p.changeStateTo(Fatal)
 	finishCb()
 }
 
-// monitor if the program is in running before endTime
-func (p *Process) monitorProgramIsRunning(endTime time.Time, monitorExited *int32, programExited *int32) {
+// monitor if the program is in running before endTime. If the readiness is configured, the
+// program must also signal it is ready before endTime, otherwise it is killed
+func (p *Process) monitorProgramIsRunning(endTime time.Time, ready *readiness, monitorExited *int32, programExited *int32) {
 	// if time is not expired
-	for time.Now().Before(endTime) && atomic.LoadInt32(programExited) == 0 {
+	for (endTime.IsZero() || time.Now().Before(endTime)) && atomic.LoadInt32(programExited) == 0 {
+		if ready != nil && ready.gating && ready.isReady() {
+			break
+		}
 		time.Sleep(time.Duration(100) * time.Millisecond)
 	}
 	atomic.StoreInt32(monitorExited, 1)
@@ -508,8 +566,16 @@ func (p *Process) monitorProgramIsRunning(endTime time.Time, monitorExited *int3
 	defer p.lock.Unlock()
 	// if the program does not exit
 	if atomic.LoadInt32(programExited) == 0 && p.state == Starting {
-		log.WithFields(log.Fields{"program": p.GetName()}).Info("success to start program")
-		p.changeStateTo(Running)
+		if ready == nil || !ready.gating || ready.isReady() {
+			log.WithFields(log.Fields{"program": p.GetName()}).Info("success to start program")
+			p.changeStateTo(Running)
+			if ready != nil {
+				ready.setReady()
+			}
+		} else {
+			log.WithFields(log.Fields{"program": p.GetName(), "timeout": ready.timeout}).Error("program is not ready in time, kill it")
+			p.sendSignal(syscall.SIGKILL, p.config.GetBool("killasgroup", p.config.GetBool("stopasgroup", false)))
+		}
 	}
 }
 
@@ -525,6 +591,8 @@ func (p *Process) run(finishCb func()) {
 
 	}
 	p.startTime = time.Now()
+	p.spawnErr = ""
+	p.notifyStatus = ""
 	atomic.StoreInt32(p.retryTimes, 0)
 	startSecs := p.getStartSeconds()
 	restartPause := p.getRestartPause()
@@ -536,14 +604,21 @@ func (p *Process) run(finishCb func()) {
 	}
 	// process is not expired and not stoped by user
 	for !p.stopByUser {
-		if restartPause > 0 && atomic.LoadInt32(p.retryTimes) != 0 {
+		if atomic.LoadInt32(p.retryTimes) != 0 && p.backoffDelay > 0 {
 			// pause
+			delay := p.backoffDelay
 			p.lock.Unlock()
-			log.WithFields(log.Fields{"program": p.GetName()}).Info("don't restart the program, start it after ", restartPause, " seconds")
-			time.Sleep(time.Duration(restartPause) * time.Second)
+			p.sleepBeforeRestart(delay)
 			p.lock.Lock()
+			if p.stopByUser {
+				p.changeStateTo(Stopped)
+				finishCbWrapper()
+				break
+			}
 		}
-		endTime := time.Now().Add(time.Duration(startSecs) * time.Second)
+		attemptStart := time.Now()
+		endTime := attemptStart.Add(time.Duration(startSecs) * time.Second)
+		p.clearRestartDelay()
 		p.changeStateTo(Starting)
 		atomic.AddInt32(p.retryTimes, 1)
 
@@ -552,14 +627,28 @@ func (p *Process) run(finishCb func()) {
 			p.failToStartProgram("fail to create program", finishCbWrapper)
 			break
 		}
+		ready, err := p.createReadiness()
+		if err != nil {
+			p.failToStartProgram(fmt.Sprintf("fail to wait for the program readiness: %v", err), finishCbWrapper)
+			break
+		}
+		if ready != nil && ready.gating && ready.timeout > 0 {
+			endTime = time.Now().Add(ready.timeout)
+		} else if ready != nil && ready.gating {
+			endTime = time.Time{}
+		}
 
 		err = p.cmd.Start()
 		if err != nil {
+			if ready != nil {
+				ready.close()
+			}
 			if atomic.LoadInt32(p.retryTimes) >= p.getStartRetries() {
 				p.failToStartProgram(fmt.Sprintf("fail to start program with error:%v", err), finishCbWrapper)
 				break
 			} else {
 				log.WithFields(log.Fields{"program": p.GetName()}).Info("fail to start program with error:", err)
+				p.computeRestartDelay(0, time.Duration(restartPause)*time.Second)
 				p.changeStateTo(Backoff)
 				continue
 			}
@@ -596,13 +685,18 @@ func (p *Process) run(finishCb func()) {
 		programExited := int32(0)
 		// Set startsec to 0 to indicate that the program needn't stay
 		// running for any particular amount of time.
-		if startSecs <= 0 {
+		if startSecs <= 0 && (ready == nil || !ready.gating) {
 			log.WithFields(log.Fields{"program": p.GetName()}).Info("success to start program")
 			p.changeStateTo(Running)
+			if ready != nil {
+				ready.setReady()
+			}
+			// no monitor thread to wait for
+			atomic.StoreInt32(&monitorExited, 1)
 			go finishCbWrapper()
 		} else {
 			go func() {
-				p.monitorProgramIsRunning(endTime, &monitorExited, &programExited)
+				p.monitorProgramIsRunning(endTime, ready, &monitorExited, &programExited)
 				finishCbWrapper()
 			}()
 		}
@@ -629,6 +723,9 @@ func (p *Process) run(finishCb func()) {
 		}
 
 		atomic.StoreInt32(&programExited, 1)
+		if ready != nil {
+			ready.close()
+		}
 		// wait for monitor thread exit
 		for atomic.LoadInt32(&monitorExited) == 0 {
 			time.Sleep(time.Duration(10) * time.Millisecond)
@@ -642,6 +739,7 @@ func (p *Process) run(finishCb func()) {
 			log.WithFields(log.Fields{"program": p.GetName()}).Info("program exited")
 			break
 		} else {
+			p.computeRestartDelay(time.Since(attemptStart), time.Duration(restartPause)*time.Second)
 			p.changeStateTo(Backoff)
 		}
 
@@ -657,32 +755,44 @@ func (p *Process) run(finishCb func()) {
 
 func (p *Process) changeStateTo(procState State) {
 	if p.config.IsProgram() {
+		pid := 0
+		if p.cmd != nil && p.cmd.Process != nil {
+			pid = p.cmd.Process.Pid
+		}
 		progName := p.config.GetProgramName()
-		groupName := p.config.GetGroupName()
+		groupName := p.GetGroup()
 		if procState == Starting {
 			events.EmitEvent(events.CreateProcessStartingEvent(progName, groupName, p.state.String(), int(atomic.LoadInt32(p.retryTimes))))
 		} else if procState == Running {
-			events.EmitEvent(events.CreateProcessRunningEvent(progName, groupName, p.state.String(), p.cmd.Process.Pid))
+			events.EmitEvent(events.CreateProcessRunningEvent(progName, groupName, p.state.String(), pid))
 		} else if procState == Backoff {
-			events.EmitEvent(events.CreateProcessBackoffEvent(progName, groupName, p.state.String(), int(atomic.LoadInt32(p.retryTimes))))
+			events.EmitEvent(events.CreateProcessBackoffEvent(progName, groupName, p.state.String(), int(atomic.LoadInt32(p.retryTimes)), p.backoffDelay))
 		} else if procState == Stopping {
-			events.EmitEvent(events.CreateProcessStoppingEvent(progName, groupName, p.state.String(), p.cmd.Process.Pid))
+			events.EmitEvent(events.CreateProcessStoppingEvent(progName, groupName, p.state.String(), pid))
 		} else if procState == Exited {
 			exitCode, err := p.getExitCode()
 			expected := 0
 			if err == nil && p.inExitCodes(exitCode) {
 				expected = 1
 			}
-			events.EmitEvent(events.CreateProcessExitedEvent(progName, groupName, p.state.String(), expected, p.cmd.Process.Pid))
+			events.EmitEvent(events.CreateProcessExitedEvent(progName, groupName, p.state.String(), expected, pid))
 		} else if procState == Fatal {
 			events.EmitEvent(events.CreateProcessFatalEvent(progName, groupName, p.state.String()))
 		} else if procState == Stopped {
-			events.EmitEvent(events.CreateProcessStoppedEvent(progName, groupName, p.state.String(), p.cmd.Process.Pid))
+			events.EmitEvent(events.CreateProcessStoppedEvent(progName, groupName, p.state.String(), pid))
 		} else if procState == Unknown {
 			events.EmitEvent(events.CreateProcessUnknownEvent(progName, groupName, p.state.String()))
 		}
 	}
+	if procState == Running {
+		p.startHealthCheck()
+	} else if p.state == Running {
+		p.stopHealthCheck()
+	}
 	p.state = procState
+	if procState == Running && p.procMgr != nil {
+		go p.procMgr.restartDependents(p)
+	}
 }
 
 // Signal sends signal to the process
@@ -728,13 +838,17 @@ func (p *Process) sendSignal(sig os.Signal, sigChildren bool) error {
 }
 
 func (p *Process) setEnv() {
+	p.cmd.Env = p.getEnv()
+}
+
+// get the environment of the program, it is also used by the health check command
+func (p *Process) getEnv() []string {
 	envFromFiles := p.config.GetEnvFromFiles("envFiles")
 	env := p.config.GetEnv("environment")
 	if len(env)+len(envFromFiles) != 0 {
-		p.cmd.Env = append(append(os.Environ(), envFromFiles...), env...)
-	} else {
-		p.cmd.Env = os.Environ()
+		return append(append(os.Environ(), envFromFiles...), env...)
 	}
+	return os.Environ()
 }
 
 func (p *Process) setDir() {
@@ -804,7 +918,7 @@ func (p *Process) setLog() {
 
 func (p *Process) createStdoutLogEventEmitter() logger.LogEventEmitter {
 	if p.config.GetBytes("stdout_capture_maxbytes", 0) <= 0 && p.config.GetBool("stdout_events_enabled", false) {
-		return logger.NewStdoutLogEventEmitter(p.config.GetProgramName(), p.config.GetGroupName(), func() int {
+		return logger.NewStdoutLogEventEmitter(p.config.GetProgramName(), p.GetGroup(), func() int {
 			return p.GetPid()
 		})
 	}
@@ -813,7 +927,7 @@ func (p *Process) createStdoutLogEventEmitter() logger.LogEventEmitter {
 
 func (p *Process) createStderrLogEventEmitter() logger.LogEventEmitter {
 	if p.config.GetBytes("stderr_capture_maxbytes", 0) <= 0 && p.config.GetBool("stderr_events_enabled", false) {
-		return logger.NewStdoutLogEventEmitter(p.config.GetProgramName(), p.config.GetGroupName(), func() int {
+		return logger.NewStdoutLogEventEmitter(p.config.GetProgramName(), p.GetGroup(), func() int {
 			return p.GetPid()
 		})
 	}
@@ -927,11 +1041,18 @@ func (p *Process) Stop(wait bool) {
 	p.lock.Lock()
 	p.stopByUser = true
 	isRunning := p.isRunning()
+	cmd := p.cmd
 	p.lock.Unlock()
 	if !isRunning {
 		log.WithFields(log.Fields{"program": p.GetName()}).Info("program is not running")
 		return
 	}
+	// the program may be started again right after it exits, don't kill the new one
+	exited := func() bool {
+		p.lock.RLock()
+		defer p.lock.RUnlock()
+		return p.cmd != cmd || (p.state != Starting && p.state != Running && p.state != Stopping)
+	}
 	log.WithFields(log.Fields{"program": p.GetName()}).Info("stop the program")
 	sigs := strings.Fields(p.config.GetString("stopsignal", "SIGTERM"))
 	waitsecs := time.Duration(p.config.GetInt("stopwaitsecs", 10)) * time.Second
@@ -956,7 +1077,7 @@ func (p *Process) Stop(wait bool) {
 			// wait at most "stopwaitsecs" seconds for one signal
 			for endTime.After(time.Now()) {
 				// if it already exits
-				if p.state != Starting && p.state != Running && p.state != Stopping {
+				if exited() {
 					atomic.StoreInt32(&stopped, 1)
 					break
 				}
@@ -969,7 +1090,7 @@ func (p *Process) Stop(wait bool) {
 			killEndTime := time.Now().Add(killwaitsecs)
 			for killEndTime.After(time.Now()) {
 				// if it exits
-				if p.state != Starting && p.state != Running && p.state != Stopping {
+				if exited() {
 					atomic.StoreInt32(&stopped, 1)
 					break
 				}
@@ -985,6 +1106,18 @@ func (p *Process) Stop(wait bool) {
 	}
 }
 
+// kill the process without waiting for it to exit, the process is not restarted
+func (p *Process) kill() {
+	p.lock.Lock()
+	p.stopByUser = true
+	isRunning := p.isRunning()
+	p.lock.Unlock()
+	if isRunning {
+		log.WithFields(log.Fields{"program": p.GetName()}).Info("force to kill the program")
+		p.Signal(syscall.SIGKILL, p.config.GetBool("killasgroup", p.config.GetBool("stopasgroup", false)))
+	}
+}
+
 // GetStatus returns status of program as a string
 func (p *Process) GetStatus() string {
 	if p.cmd.ProcessState.Exited() {
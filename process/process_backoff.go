@@ -0,0 +1,116 @@
+package process
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// restartBackoff the exponential backoff between the start attempts of a program. It is enabled
+// by restart_backoff_initial, all the values are in seconds:
+//
+//	restart_backoff_initial - the delay before the first restart
+//	restart_backoff_multiplier - the delay is multiplied by it after every restart, default is 2
+//	restart_backoff_max - the max delay, default is 300
+//	restart_backoff_jitter - the delay is randomized by +/- this fraction, default is 0.2
+//	restart_backoff_reset - the delay is reset if the program runs longer than it, default is 60
+type restartBackoff struct {
+	initial    time.Duration
+	multiplier float64
+	max        time.Duration
+	jitter     float64
+	reset      time.Duration
+}
+
+// get the restart backoff of the program, false is returned if it is not configured
+func (p *Process) getRestartBackoff() (restartBackoff, bool) {
+	b := restartBackoff{
+		initial:    p.getSeconds("restart_backoff_initial", 0),
+		multiplier: p.getFloat("restart_backoff_multiplier", 2),
+		max:        p.getSeconds("restart_backoff_max", 300),
+		jitter:     math.Min(math.Max(p.getFloat("restart_backoff_jitter", 0.2), 0), 1),
+		reset:      p.getSeconds("restart_backoff_reset", 60),
+	}
+	if b.multiplier < 1 {
+		b.multiplier = 1
+	}
+	if b.max < b.initial {
+		b.max = b.initial
+	}
+	return b, b.initial > 0
+}
+
+// get the delay of the nth restart
+func (b restartBackoff) delay(attempts int) time.Duration {
+	delay := math.Min(float64(b.initial)*math.Pow(b.multiplier, float64(attempts)), float64(b.max))
+	delay *= 1 + b.jitter*(2*rand.Float64()-1)
+	return time.Duration(delay)
+}
+
+func (p *Process) getFloat(key string, defValue float64) float64 {
+	value, err := strconv.ParseFloat(p.config.GetString(key, ""), 64)
+	if err != nil {
+		return defValue
+	}
+	return value
+}
+
+func (p *Process) getSeconds(key string, defValue float64) time.Duration {
+	return time.Duration(p.getFloat(key, defValue) * float64(time.Second))
+}
+
+// compute the delay before the next start attempt of the program which ran for runTime. The
+// legacyDelay is used if the restart backoff is not configured. It must be called with the lock
+func (p *Process) computeRestartDelay(runTime time.Duration, legacyDelay time.Duration) time.Duration {
+	delay := legacyDelay
+	if b, ok := p.getRestartBackoff(); ok {
+		if runTime >= b.reset {
+			p.backoffAttempts = 0
+		}
+		delay = b.delay(p.backoffAttempts)
+		p.backoffAttempts++
+	}
+	p.backoffDelay = delay
+	if delay > 0 {
+		p.nextAttempt = time.Now().Add(delay)
+	} else {
+		p.nextAttempt = time.Time{}
+	}
+	return delay
+}
+
+// clear the delay of the next start attempt, it must be called with the lock
+func (p *Process) clearRestartDelay() {
+	p.backoffDelay = 0
+	p.nextAttempt = time.Time{}
+}
+
+// sleep the delay before the next start attempt, returns early if the program is stopped by user.
+// The delay is cleared after sleeping
+func (p *Process) sleepBeforeRestart(delay time.Duration) {
+	log.WithFields(log.Fields{"program": p.GetName(), "delay": delay}).Info("wait before starting the program again")
+	endTime := time.Now().Add(delay)
+	for time.Now().Before(endTime) {
+		p.lock.RLock()
+		stopByUser := p.stopByUser
+		p.lock.RUnlock()
+		if stopByUser {
+			break
+		}
+		time.Sleep(min(100*time.Millisecond, time.Until(endTime)))
+	}
+	p.lock.Lock()
+	p.clearRestartDelay()
+	p.lock.Unlock()
+}
+
+// GetBackoff returns the delay before the next start attempt and the time of the attempt, the
+// time is zero if no start attempt is scheduled
+func (p *Process) GetBackoff() (time.Duration, time.Time) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.backoffDelay, p.nextAttempt
+}
@@ -0,0 +1,69 @@
+package process
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestartBackoffDelay(t *testing.T) {
+	b := restartBackoff{initial: time.Second, multiplier: 2, max: 10 * time.Second}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{3, 8 * time.Second},
+		{4, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+	for _, test := range tests {
+		if got := b.delay(test.attempts); got != test.want {
+			t.Errorf("delay(%d) = %v, want %v", test.attempts, got, test.want)
+		}
+	}
+
+	b.jitter = 0.2
+	for i := 0; i < 100; i++ {
+		if got := b.delay(2); got < 3200*time.Millisecond || got > 4800*time.Millisecond {
+			t.Fatalf("delay(2) with jitter 0.2 = %v, want in [3.2s, 4.8s]", got)
+		}
+	}
+}
+
+func TestGetRestartBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    string
+		enabled bool
+		want    restartBackoff
+	}{
+		{
+			name: "not configured",
+			conf: "",
+			want: restartBackoff{multiplier: 2, max: 300 * time.Second, jitter: 0.2, reset: 60 * time.Second},
+		},
+		{
+			name:    "defaults",
+			conf:    "restart_backoff_initial=0.5\n",
+			enabled: true,
+			want:    restartBackoff{initial: 500 * time.Millisecond, multiplier: 2, max: 300 * time.Second, jitter: 0.2, reset: 60 * time.Second},
+		},
+		{
+			name: "out of range values are limited",
+			conf: "restart_backoff_initial=10\nrestart_backoff_multiplier=0.5\nrestart_backoff_max=1\n" +
+				"restart_backoff_jitter=3\nrestart_backoff_reset=5\n",
+			enabled: true,
+			want:    restartBackoff{initial: 10 * time.Second, multiplier: 1, max: 10 * time.Second, jitter: 1, reset: 5 * time.Second},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proc := newTestProcesses(t, "[program:a]\ncommand=/bin/true\n"+test.conf)[0]
+			got, enabled := proc.getRestartBackoff()
+			if got != test.want || enabled != test.enabled {
+				t.Errorf("getRestartBackoff() = %+v, %v, want %+v, %v", got, enabled, test.want, test.enabled)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package process
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// the actions when the restart limit of a program is exceeded
+const (
+	restartLimitActionNone      = "none"
+	restartLimitActionStopGroup = "stop_group"
+	restartLimitActionShutdown  = "shutdown"
+)
+
+// record an automatic restart of the program and check the restart limit:
+//
+//	restart_limit - the max automatic restarts in the window, 0 for no limit
+//	restart_limit_window - the window in seconds, default is 60
+//
+// returns the reason if the limit is exceeded. It must be called with the lock
+func (p *Process) recordRestart() (string, bool) {
+	limit := p.config.GetInt("restart_limit", 0)
+	if limit <= 0 {
+		return "", false
+	}
+	window := time.Duration(p.config.GetInt("restart_limit_window", 60)) * time.Second
+	now := time.Now()
+	history := make([]time.Time, 0, len(p.restartHistory)+1)
+	for _, t := range p.restartHistory {
+		if now.Sub(t) < window {
+			history = append(history, t)
+		}
+	}
+	p.restartHistory = append(history, now)
+	if len(p.restartHistory) <= limit {
+		return "", false
+	}
+	return fmt.Sprintf("restarted more than %d times in %v", limit, window), true
+}
+
+// check the restart limit before restarting the program automatically. If the limit is exceeded,
+// the program enters Fatal state and the restart_limit_action is taken:
+//
+//	none - only the program is not restarted, it is the default
+//	stop_group - stop all the programs in the group of the program
+//	shutdown - stop all the programs and shut down supervisord
+//
+// returns true if the program must not be restarted
+func (p *Process) checkRestartLimit() bool {
+	p.lock.Lock()
+	reason, exceeded := p.recordRestart()
+	if !exceeded {
+		p.lock.Unlock()
+		return false
+	}
+	p.breakerTripped = true
+	p.spawnErr = reason
+	if p.state != Fatal {
+		p.changeStateTo(Fatal)
+	}
+	p.lock.Unlock()
+
+	action := p.config.GetString("restart_limit_action", restartLimitActionNone)
+	log.WithFields(log.Fields{"program": p.GetName(), "action": action}).Error(reason + ", don't restart it")
+	if p.procMgr != nil {
+		p.procMgr.onRestartLimitExceeded(p, action, reason)
+	}
+	return true
+}
+
+// ResetRestartBreaker clears the restart history of the program so it can be restarted
+// automatically again, returns true if the restart limit was exceeded
+func (p *Process) ResetRestartBreaker() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	tripped := p.breakerTripped
+	p.breakerTripped = false
+	p.restartHistory = nil
+	if tripped && p.state == Fatal {
+		p.spawnErr = ""
+	}
+	return tripped
+}
+
+// SetShutdownHandler sets the function to shut down supervisord when the restart limit of a
+// program with "restart_limit_action=shutdown" is exceeded
+func (pm *Manager) SetShutdownHandler(handler func(reason string)) {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+	pm.shutdownHandler = handler
+}
+
+// take the restart_limit_action of the program
+func (pm *Manager) onRestartLimitExceeded(proc *Process, action string, reason string) {
+	switch action {
+	case restartLimitActionStopGroup:
+		go func() {
+			procs := pm.FindGroup(proc.GetGroup())
+			for i := len(procs) - 1; i >= 0; i-- {
+				if procs[i] != proc {
+					procs[i].Stop(true)
+				}
+			}
+		}()
+	case restartLimitActionShutdown:
+		pm.lock.Lock()
+		handler := pm.shutdownHandler
+		pm.lock.Unlock()
+		if handler != nil {
+			go handler(fmt.Sprintf("program %s %s", proc.GetName(), reason))
+		}
+	case restartLimitActionNone:
+	default:
+		log.WithFields(log.Fields{"program": proc.GetName(), "action": action}).Error("unknown restart_limit_action")
+	}
+}
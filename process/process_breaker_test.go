@@ -0,0 +1,65 @@
+package process
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordRestart(t *testing.T) {
+	tests := []struct {
+		name string
+		conf string
+		// the ages of the restarts already recorded
+		history  []time.Duration
+		exceeded bool
+		// the number of the restarts in the history after recording
+		recorded int
+	}{
+		{
+			name:     "no limit",
+			conf:     "",
+			history:  []time.Duration{time.Second, 2 * time.Second},
+			recorded: 2,
+		},
+		{
+			name:     "under the limit",
+			conf:     "restart_limit=3\n",
+			history:  []time.Duration{time.Second},
+			recorded: 2,
+		},
+		{
+			name:     "at the limit",
+			conf:     "restart_limit=2\n",
+			history:  []time.Duration{time.Second},
+			recorded: 2,
+		},
+		{
+			name:     "over the limit",
+			conf:     "restart_limit=2\n",
+			history:  []time.Duration{time.Second, 2 * time.Second},
+			exceeded: true,
+			recorded: 3,
+		},
+		{
+			name:     "old restarts are out of the window",
+			conf:     "restart_limit=2\nrestart_limit_window=10\n",
+			history:  []time.Duration{time.Second, 20 * time.Second, 30 * time.Second},
+			recorded: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proc := newTestProcesses(t, "[program:a]\ncommand=/bin/true\n"+test.conf)[0]
+			for _, age := range test.history {
+				proc.restartHistory = append(proc.restartHistory, time.Now().Add(-age))
+			}
+			reason, exceeded := proc.recordRestart()
+			if exceeded != test.exceeded || (exceeded && reason == "") {
+				t.Errorf("recordRestart() = %q, %v, want exceeded %v", reason, exceeded, test.exceeded)
+			}
+			if len(proc.restartHistory) != test.recorded {
+				t.Errorf("%d restarts are recorded, want %d", len(proc.restartHistory), test.recorded)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package process
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// the interval to check the state of the depends_on programs
+	dependencyPollingTime = 100 * time.Millisecond
+	// the time to wait for a stopped or exited depends_on program to be started
+	dependencyStartTimeout = 30 * time.Second
+)
+
+// get the names of the programs this program depends on
+func (p *Process) getDependsOn() []string {
+	result := make([]string, 0)
+	for _, name := range strings.Split(p.config.GetString("depends_on", ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// check if the program is ready to be depended on: it is Running and passes its health check
+func (p *Process) isReady() bool {
+	health := p.GetHealth()
+	return p.GetState() == Running && (health == HealthNone || health == HealthHealthy)
+}
+
+// IsReady returns true if the process is Running and passes its health check if it has one
+func (p *Process) IsReady() bool {
+	return p.isReady()
+}
+
+// check the depends_on programs, returns the names of the programs not ready yet, the names of
+// the programs which are not being started, and an error if a program does not exist or is Fatal.
+// A program with numprocs is ready if all its instances are ready
+func (p *Process) checkDependencies() ([]string, []string, error) {
+	pending := make([]string, 0)
+	idle := make([]string, 0)
+	for _, name := range p.getDependsOn() {
+		instances := p.procMgr.findProgram(name)
+		if len(instances) == 0 {
+			return nil, nil, fmt.Errorf("dependency %s does not exist", name)
+		}
+		ready, starting := true, false
+		for _, dependency := range instances {
+			state := dependency.GetState()
+			if state == Fatal {
+				return nil, nil, fmt.Errorf("dependency %s is in Fatal state", dependency.GetName())
+			}
+			if !dependency.isReady() {
+				ready = false
+			}
+			if state != Stopped && state != Exited && state != Unknown {
+				starting = true
+			}
+		}
+		if !ready {
+			pending = append(pending, name)
+			if !starting {
+				idle = append(idle, name)
+			}
+		}
+	}
+	return pending, idle, nil
+}
+
+// wait until all the depends_on programs are ready, the process is in Waiting state during
+// the waiting. Returns false if a dependency fails, a dependency is not started in
+// dependencyStartTimeout or the process is stopped by user
+func (p *Process) waitForDependencies() bool {
+	if p.procMgr == nil || len(p.getDependsOn()) == 0 {
+		return true
+	}
+	var idleSince time.Time
+	for {
+		pending, idle, err := p.checkDependencies()
+		if err == nil && len(idle) == 0 {
+			idleSince = time.Time{}
+		} else if err == nil && idleSince.IsZero() {
+			idleSince = time.Now()
+		} else if err == nil && time.Since(idleSince) >= dependencyStartTimeout {
+			err = fmt.Errorf("dependency %s is not started in %v", strings.Join(idle, ", "), dependencyStartTimeout)
+		}
+
+		p.lock.Lock()
+		if p.stopByUser {
+			if p.state == Waiting {
+				p.spawnErr = ""
+				p.changeStateTo(Stopped)
+			}
+			p.lock.Unlock()
+			return false
+		}
+		if err != nil {
+			log.WithFields(log.Fields{"program": p.GetName(), log.ErrorKey: err}).Error("fail to start program")
+			p.spawnErr = err.Error()
+			p.changeStateTo(Fatal)
+			p.lock.Unlock()
+			return false
+		}
+		if len(pending) == 0 {
+			p.lock.Unlock()
+			return true
+		}
+		if p.state != Waiting {
+			log.WithFields(log.Fields{"program": p.GetName(), "dependencies": pending}).Info("wait for the dependencies to be ready")
+			p.changeStateTo(Waiting)
+		}
+		p.spawnErr = fmt.Sprintf("waiting for %s", strings.Join(pending, ", "))
+		p.lock.Unlock()
+
+		time.Sleep(dependencyPollingTime)
+	}
+}
+
+// find the instances of the program created from the [program:x] section
+func (pm *Manager) findProgram(name string) []*Process {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+	result := make([]*Process, 0)
+	for _, proc := range pm.procs {
+		if proc.config.IsProgram() && proc.config.GetSectionProgramName() == name {
+			result = append(result, proc)
+		}
+	}
+	return result
+}
+
+// restart the running programs which depend on proc, have cascade_restart enabled and were
+// started before the latest start of proc
+func (pm *Manager) restartDependents(proc *Process) {
+	pm.lock.Lock()
+	dependents := make([]*Process, 0)
+	for _, dependent := range pm.procs {
+		if dependent.config.GetBool("cascade_restart", false) && containsName(dependent.getDependsOn(), proc.config.GetSectionProgramName()) {
+			dependents = append(dependents, dependent)
+		}
+	}
+	pm.lock.Unlock()
+
+	for _, dependent := range dependents {
+		if dependent.GetState() == Running && dependent.GetStartTime().Before(proc.GetStartTime()) {
+			log.WithFields(log.Fields{"program": dependent.GetName(), "dependency": proc.GetName()}).Info("restart program because its dependency is restarted")
+			go dependent.Restart(false)
+		}
+	}
+}
+
+// Restart stops the process if it is running and starts it again after the previous start loop
+// is finished, Start does nothing while the previous start loop is running
+// Args:
+//
+//	wait - true, wait the program started or failed
+func (p *Process) Restart(wait bool) {
+	p.Stop(true)
+	for i := 0; i < 100; i++ {
+		p.lock.RLock()
+		inStart := p.inStart
+		p.lock.RUnlock()
+		if !inStart {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	p.Start(wait)
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
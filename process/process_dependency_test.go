@@ -0,0 +1,90 @@
+package process
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckDependencies(t *testing.T) {
+	conf := "[program:db]\ncommand=/bin/true\nnumprocs=2\nprocess_name=db_%(process_num)d\n" +
+		"[program:cache]\ncommand=/bin/true\n" +
+		"[program:app]\ncommand=/bin/true\ndepends_on=db,cache\n"
+
+	tests := []struct {
+		name    string
+		states  map[string]State
+		pending string
+		idle    string
+		err     string
+	}{
+		{
+			name:   "all ready",
+			states: map[string]State{"db_1": Running, "db_2": Running, "cache": Running},
+		},
+		{
+			name:    "one instance is starting",
+			states:  map[string]State{"db_1": Running, "db_2": Starting, "cache": Running},
+			pending: "db",
+		},
+		{
+			name:    "one instance is stopped and the other is starting",
+			states:  map[string]State{"db_1": Stopped, "db_2": Backoff, "cache": Running},
+			pending: "db",
+		},
+		{
+			name:    "not started",
+			states:  map[string]State{"db_1": Running, "db_2": Running, "cache": Stopped},
+			pending: "cache",
+			idle:    "cache",
+		},
+		{
+			name:    "exited",
+			states:  map[string]State{"db_1": Exited, "db_2": Exited, "cache": Starting},
+			pending: "db,cache",
+			idle:    "db",
+		},
+		{
+			name:   "fatal instance",
+			states: map[string]State{"db_1": Running, "db_2": Fatal, "cache": Running},
+			err:    "dependency db_2 is in Fatal state",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pm := NewManager()
+			var app *Process
+			for _, proc := range newTestProcesses(t, conf) {
+				pm.Add(proc.GetName(), proc)
+				proc.state = test.states[proc.GetName()]
+				if proc.GetName() == "app" {
+					app = proc
+				}
+			}
+			pending, idle, err := app.checkDependencies()
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("checkDependencies() error = %v, want %s", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("checkDependencies() error = %v", err)
+			}
+			if strings.Join(pending, ",") != test.pending || strings.Join(idle, ",") != test.idle {
+				t.Errorf("checkDependencies() = %v, %v, want %s, %s", pending, idle, test.pending, test.idle)
+			}
+		})
+	}
+
+	t.Run("dependency is not added", func(t *testing.T) {
+		pm := NewManager()
+		for _, proc := range newTestProcesses(t, conf) {
+			if proc.GetName() == "app" {
+				pm.Add(proc.GetName(), proc)
+				if _, _, err := proc.checkDependencies(); err == nil || err.Error() != "dependency db does not exist" {
+					t.Errorf("checkDependencies() error = %v, want dependency db does not exist", err)
+				}
+			}
+		}
+	})
+}
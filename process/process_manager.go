@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ochinchina/supervisord/config"
 	log "github.com/sirupsen/logrus"
@@ -13,7 +14,11 @@ import (
 type Manager struct {
 	procs          map[string]*Process
 	eventListeners map[string]*Process
-	lock           sync.Mutex
+	// shut down supervisord when a program exceeds its restart limit
+	shutdownHandler func(reason string)
+	// restart the instances of a program one by one when its binary is changed
+	rollingRestartHandler func(proc *Process)
+	lock                  sync.Mutex
 }
 
 // NewManager creates new Manager object
@@ -37,6 +42,26 @@ func (pm *Manager) CreateProcess(supervisorID string, config *config.Entry) *Pro
 	}
 }
 
+// SetRollingRestartHandler sets the function to restart the instances of a program with numprocs > 1
+// one by one when its binary is changed
+func (pm *Manager) SetRollingRestartHandler(handler func(proc *Process)) {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+	pm.rollingRestartHandler = handler
+}
+
+// restart the instances of the program of the process one by one, returns false if no handler is set
+func (pm *Manager) rollingRestart(proc *Process) bool {
+	pm.lock.Lock()
+	handler := pm.rollingRestartHandler
+	pm.lock.Unlock()
+	if handler == nil {
+		return false
+	}
+	handler(proc)
+	return true
+}
+
 // StartAutoStartPrograms starts all programs that set as should be autostarted
 func (pm *Manager) StartAutoStartPrograms() {
 	pm.ForEachProcess(func(proc *Process) {
@@ -53,6 +78,7 @@ func (pm *Manager) createProgram(supervisorID string, config *config.Entry) *Pro
 
 	if !ok {
 		proc = NewProcess(supervisorID, config)
+		proc.procMgr = pm
 		pm.procs[procName] = proc
 	}
 	log.Info("create process:", procName)
@@ -76,6 +102,7 @@ func (pm *Manager) createEventListener(supervisorID string, config *config.Entry
 func (pm *Manager) Add(name string, proc *Process) {
 	pm.lock.Lock()
 	defer pm.lock.Unlock()
+	proc.procMgr = pm
 	pm.procs[name] = proc
 	log.Info("add process:", name)
 }
@@ -136,6 +163,20 @@ func (pm *Manager) FindMatch(name string) []*Process {
 	return result
 }
 
+// FindGroup returns the processes in the group sorted by depends_on and priority
+func (pm *Manager) FindGroup(group string) []*Process {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+
+	procs := make([]*Process, 0)
+	for _, proc := range pm.procs {
+		if proc.GetGroup() == group {
+			procs = append(procs, proc)
+		}
+	}
+	return sortProcess(procs)
+}
+
 // Clear all the processes from Manager object
 func (pm *Manager) Clear() {
 	pm.lock.Lock()
@@ -184,21 +225,92 @@ func (pm *Manager) getAllProcess() []*Process {
 	return sortProcess(tmpProcs)
 }
 
-// StopAllProcesses stop all the processes listed in Manager object
-func (pm *Manager) StopAllProcesses() {
-	var wg sync.WaitGroup
+// StopAllProcesses stops all the processes in the reverse depends_on/priority order: the
+// dependents are stopped before their dependencies, only the processes in the same priority
+// tier are stopped in parallel and the event listeners are stopped at last. If timeout is
+// positive, the processes still running after timeout are killed
+func (pm *Manager) StopAllProcesses(timeout time.Duration) {
+	pm.lock.Lock()
+	procs := pm.getAllProcess()
+	listeners := make([]*Process, 0)
+	for _, listener := range pm.eventListeners {
+		listeners = append(listeners, listener)
+	}
+	pm.lock.Unlock()
 
-	pm.ForEachProcess(func(proc *Process) {
-		wg.Add(1)
+	tiers := getStopTiers(procs)
+	if len(listeners) > 0 {
+		tiers = append(tiers, listeners)
+	}
+	deadline := time.Now().Add(timeout)
+	for _, tier := range tiers {
+		var wg sync.WaitGroup
+		for _, proc := range tier {
+			wg.Add(1)
+			go func(proc *Process) {
+				defer wg.Done()
+				proc.Stop(true)
+			}(proc)
+		}
+		if !waitBefore(&wg, deadline, timeout > 0) {
+			log.WithFields(log.Fields{"timeout": timeout}).Warn("shutdown deadline is reached, kill all the processes")
+			for _, proc := range append(procs, listeners...) {
+				proc.kill()
+			}
+			return
+		}
+	}
+}
 
-		go func(wg *sync.WaitGroup) {
-			defer wg.Done()
+// split the processes sorted in the start order to the tiers in the stop order. The processes
+// in one tier have the same priority and none of them depends on another one in the tier
+func getStopTiers(procs []*Process) [][]*Process {
+	tiers := make([][]*Process, 0)
+	tier := make([]*Process, 0)
+	for i := len(procs) - 1; i >= 0; i-- {
+		proc := procs[i]
+		if len(tier) > 0 && (tier[0].GetPriority() != proc.GetPriority() || isDependencyOf(proc, tier)) {
+			tiers = append(tiers, tier)
+			tier = make([]*Process, 0)
+		}
+		tier = append(tier, proc)
+	}
+	if len(tier) > 0 {
+		tiers = append(tiers, tier)
+	}
+	return tiers
+}
 
-			proc.Stop(true)
-		}(&wg)
-	})
+// check if any of the processes depends on the program of proc
+func isDependencyOf(proc *Process, procs []*Process) bool {
+	for _, p := range procs {
+		for _, name := range p.getDependsOn() {
+			if name == proc.config.GetSectionProgramName() {
+				return true
+			}
+		}
+	}
+	return false
+}
 
-	wg.Wait()
+// wait for the WaitGroup until the deadline if hasDeadline is true, returns false if the
+// deadline is reached
+func waitBefore(wg *sync.WaitGroup, deadline time.Time, hasDeadline bool) bool {
+	if !hasDeadline {
+		wg.Wait()
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(time.Until(deadline)):
+		return false
+	}
 }
 
 func sortProcess(procs []*Process) []*Process {
@@ -0,0 +1,81 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ochinchina/supervisord/config"
+)
+
+// create the processes of the programs in the configuration, sorted in the start order
+func newTestProcesses(t *testing.T, conf string) []*Process {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "supervisord.conf")
+	if err := os.WriteFile(file, []byte(conf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.NewConfig(file)
+	if _, _, err := cfg.Load(); err != nil {
+		t.Fatal(err)
+	}
+	procs := make([]*Process, 0)
+	for _, entry := range cfg.GetPrograms() {
+		procs = append(procs, NewProcess("test", entry))
+	}
+	return procs
+}
+
+func getTierNames(tiers [][]*Process) []string {
+	result := make([]string, 0)
+	for _, tier := range tiers {
+		names := make([]string, 0)
+		for _, proc := range tier {
+			names = append(names, proc.GetName())
+		}
+		// the order in a tier is not defined
+		sort.Strings(names)
+		result = append(result, strings.Join(names, ","))
+	}
+	return result
+}
+
+func TestGetStopTiers(t *testing.T) {
+	tests := []struct {
+		name string
+		conf string
+		want []string
+	}{
+		{
+			name: "same priority without dependencies",
+			conf: "[program:a]\ncommand=/bin/true\n[program:b]\ncommand=/bin/true\n",
+			want: []string{"a,b"},
+		},
+		{
+			name: "priorities",
+			conf: "[program:a]\ncommand=/bin/true\npriority=1\n[program:b]\ncommand=/bin/true\npriority=2\n",
+			want: []string{"b", "a"},
+		},
+		{
+			name: "dependency",
+			conf: "[program:db]\ncommand=/bin/true\n[program:app]\ncommand=/bin/true\ndepends_on=db\n",
+			want: []string{"app", "db"},
+		},
+		{
+			name: "dependency with numprocs",
+			conf: "[program:db]\ncommand=/bin/true\nnumprocs=2\nprocess_name=db_%(process_num)d\n" +
+				"[program:app]\ncommand=/bin/true\ndepends_on=db\n",
+			want: []string{"app", "db_1,db_2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := getTierNames(getStopTiers(newTestProcesses(t, test.conf)))
+			if strings.Join(got, " ") != strings.Join(test.want, " ") {
+				t.Errorf("getStopTiers() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
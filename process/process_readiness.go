@@ -0,0 +1,255 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// the max length of a log line kept for matching the ready_log_regex
+const maxReadyLogLineSize = 64 * 1024
+
+// readiness waits for the program to declare it is ready instead of the "startsecs" timer.
+// The program is ready when one of the configured signals is received:
+//
+//	ready_notify=true - "READY=1" is sent to the sd_notify compatible $NOTIFY_SOCKET
+//	ready_file - the file is created after the program is started
+//	ready_log_regex - a line of the stdout or stderr matches the regex
+//
+// If watchdog_sec is set, the program must send "WATCHDOG=1" to $NOTIFY_SOCKET at least once
+// every watchdog_sec seconds after it is ready, otherwise it is restarted
+type readiness struct {
+	proc *Process
+	// false if only the watchdog is configured, the "startsecs" timer is used in this case
+	gating     bool
+	startTime  time.Time
+	timeout    time.Duration
+	watchdog   time.Duration
+	readyFile  string
+	notifyPath string
+	notifyConn *net.UnixConn
+	ready      chan struct{}
+	readyOnce  sync.Once
+	// the last time in nanoseconds the "WATCHDOG=1" is received
+	lastPing  int64
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// create the readiness of the started command from the program configuration, nil is returned
+// if no readiness signal is configured. It must be called with the lock before the command starts
+func (p *Process) createReadiness() (*readiness, error) {
+	notify := p.config.GetBool("ready_notify", false)
+	readyFile := p.config.GetStringExpression("ready_file", "")
+	logRegex := p.config.GetString("ready_log_regex", "")
+	watchdog := time.Duration(p.config.GetInt("watchdog_sec", 0)) * time.Second
+	if !p.config.IsProgram() || (!notify && readyFile == "" && logRegex == "" && watchdog <= 0) {
+		return nil, nil
+	}
+
+	r := &readiness{
+		proc:      p,
+		gating:    notify || readyFile != "" || logRegex != "",
+		startTime: time.Now(),
+		timeout:   time.Duration(p.config.GetInt("ready_timeout", 60)) * time.Second,
+		watchdog:  watchdog,
+		ready:     make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if readyFile != "" {
+		if dir := p.config.GetStringExpression("directory", ""); dir != "" && !filepath.IsAbs(readyFile) {
+			readyFile = filepath.Join(dir, readyFile)
+		}
+		r.readyFile = readyFile
+		go r.waitReadyFile()
+	}
+	if logRegex != "" {
+		regex, err := regexp.Compile(logRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ready_log_regex %s: %v", logRegex, err)
+		}
+		p.cmd.Stdout = &readyLogMatcher{writer: p.cmd.Stdout, regex: regex, matched: r.setReady}
+		p.cmd.Stderr = &readyLogMatcher{writer: p.cmd.Stderr, regex: regex, matched: r.setReady}
+	}
+	if notify || watchdog > 0 {
+		if err := r.listenNotifySocket(); err != nil {
+			r.close()
+			return nil, err
+		}
+		p.cmd.Env = append(p.cmd.Env, "NOTIFY_SOCKET="+r.notifyPath)
+		if watchdog > 0 {
+			p.cmd.Env = append(p.cmd.Env, fmt.Sprintf("WATCHDOG_USEC=%d", watchdog.Microseconds()))
+		}
+	}
+	return r, nil
+}
+
+// listen on the datagram socket passed to the program in $NOTIFY_SOCKET
+func (r *readiness) listenNotifySocket() error {
+	name := strings.NewReplacer("/", "_", ":", "_").Replace(r.proc.GetName())
+	r.notifyPath = filepath.Join(os.TempDir(), fmt.Sprintf("supervisord-%d-%s.sock", os.Getpid(), name))
+	os.Remove(r.notifyPath)
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: r.notifyPath, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("fail to listen on notify socket %s: %v", r.notifyPath, err)
+	}
+	r.notifyConn = conn
+	if uid, gid, ok := getUserID(r.proc.cmd.SysProcAttr); ok {
+		if err := os.Chown(r.notifyPath, int(uid), int(gid)); err != nil {
+			log.WithFields(log.Fields{"program": r.proc.GetName(), "file": r.notifyPath, log.ErrorKey: err}).Warn("fail to change the owner of the notify socket")
+		}
+	}
+	go r.readNotifications()
+	return nil
+}
+
+// read the sd_notify messages until the socket is closed
+func (r *readiness) readNotifications() {
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.notifyConn.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			r.handleNotification(line)
+		}
+	}
+}
+
+func (r *readiness) handleNotification(line string) {
+	switch {
+	case line == "READY=1":
+		r.setReady()
+	case line == "WATCHDOG=1":
+		atomic.StoreInt64(&r.lastPing, time.Now().UnixNano())
+	case line == "WATCHDOG=trigger":
+		r.watchdogExpired("the program triggers the watchdog")
+	case strings.HasPrefix(line, "STATUS="):
+		r.proc.lock.Lock()
+		r.proc.notifyStatus = strings.TrimPrefix(line, "STATUS=")
+		r.proc.lock.Unlock()
+	}
+}
+
+// wait until the ready file is created after the program is started
+func (r *readiness) waitReadyFile() {
+	// the modification time may be truncated to seconds by the file system
+	startTime := r.startTime.Truncate(time.Second)
+	for {
+		if info, err := os.Stat(r.readyFile); err == nil && !info.ModTime().Before(startTime) {
+			r.setReady()
+			return
+		}
+		select {
+		case <-r.done:
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (r *readiness) setReady() {
+	r.readyOnce.Do(func() {
+		log.WithFields(log.Fields{"program": r.proc.GetName()}).Info("program is ready")
+		atomic.StoreInt64(&r.lastPing, time.Now().UnixNano())
+		close(r.ready)
+		if r.watchdog > 0 {
+			go r.runWatchdog()
+		}
+	})
+}
+
+func (r *readiness) isReady() bool {
+	select {
+	case <-r.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// restart the program if no "WATCHDOG=1" is received in the watchdog_sec
+func (r *readiness) runWatchdog() {
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-time.After(r.watchdog / 4):
+		}
+		if time.Since(time.Unix(0, atomic.LoadInt64(&r.lastPing))) > r.watchdog {
+			r.watchdogExpired(fmt.Sprintf("no watchdog notification in %v", r.watchdog))
+			return
+		}
+	}
+}
+
+func (r *readiness) watchdogExpired(reason string) {
+	select {
+	case <-r.done:
+		return
+	default:
+	}
+	if r.proc.GetState() != Running {
+		return
+	}
+	log.WithFields(log.Fields{"program": r.proc.GetName()}).Warn(reason + ", restart the program")
+	r.close()
+	go r.proc.Restart(false)
+}
+
+// stop waiting for the signals of the program and remove the notify socket
+func (r *readiness) close() {
+	r.closeOnce.Do(func() {
+		close(r.done)
+		if r.notifyConn != nil {
+			r.notifyConn.Close()
+			os.Remove(r.notifyPath)
+		}
+	})
+}
+
+// readyLogMatcher writes the log of the program and checks every line with the ready_log_regex
+// until a line is matched
+type readyLogMatcher struct {
+	writer  io.Writer
+	regex   *regexp.Regexp
+	matched func()
+	line    []byte
+	done    bool
+}
+
+// Write implements io.Writer interface
+func (m *readyLogMatcher) Write(b []byte) (int, error) {
+	if !m.done {
+		m.line = append(m.line, b...)
+		for !m.done {
+			pos := bytes.IndexByte(m.line, '\n')
+			if pos < 0 {
+				break
+			}
+			if m.regex.Match(m.line[:pos]) {
+				m.done = true
+				m.matched()
+			}
+			m.line = m.line[pos+1:]
+		}
+		if m.done || len(m.line) > maxReadyLogLineSize {
+			m.line = nil
+		}
+	}
+	if m.writer == nil {
+		return len(b), nil
+	}
+	return m.writer.Write(b)
+}
@@ -22,3 +22,11 @@ func setUserID(procAttr *syscall.SysProcAttr, uid uint32, gid uint32) {
 	}
 	procAttr.Credential = &syscall.Credential{Uid: uid, Gid: gid, NoSetGroups: true}
 }
+
+// get the user and group the program runs as, ok is false if the user is not switched
+func getUserID(procAttr *syscall.SysProcAttr) (uid uint32, gid uint32, ok bool) {
+	if procAttr == nil || procAttr.Credential == nil {
+		return 0, 0, false
+	}
+	return procAttr.Credential.Uid, procAttr.Credential.Gid, true
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"time"
+
+	"github.com/ochinchina/supervisord/faults"
+	"github.com/ochinchina/supervisord/process"
+	log "github.com/sirupsen/logrus"
+)
+
+// the default time to wait for a whole group to be started, stopped or restarted
+const defaultGroupOperationTimeout = 60 * time.Second
+
+// the interval to check the state of the process in the group operations
+const groupOperationPollingTime = 100 * time.Millisecond
+
+// ProcessGroupArgs arguments for starting, stopping or restarting a group
+type ProcessGroupArgs struct {
+	Name    string // group name
+	Wait    bool   `default:"true"` // wait every process reaching Running or Stopped before handling the next one
+	Timeout int    // the seconds to wait for the whole group, 0 for defaultGroupOperationTimeout
+}
+
+// get the timeout of the group operation from the seconds in the request
+func groupOperationTimeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		return defaultGroupOperationTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// wait until the process is not in the state accepted by inState or the deadline is reached,
+// returns the last state of the process
+func waitProcessLeaveStates(proc *process.Process, deadline time.Time, inState func(process.State) bool) process.State {
+	for {
+		state := proc.GetState()
+		if !inState(state) || !time.Now().Before(deadline) {
+			return state
+		}
+		time.Sleep(groupOperationPollingTime)
+	}
+}
+
+func isStartingState(state process.State) bool {
+	return state == process.Starting || state == process.Backoff || state == process.Waiting
+}
+
+func isStoppingState(state process.State) bool {
+	return isRunningState(state) || state == process.Stopping
+}
+
+// start the process and wait until it is Running, Fatal or the deadline is reached
+func startProcessBefore(proc *process.Process, deadline time.Time) RPCTaskResult {
+	if isRunningState(proc.GetState()) {
+		return newRPCTaskResult(proc, faults.AlreadyStated, "ALREADY_STARTED")
+	}
+	started := make(chan struct{})
+	go func() {
+		// the start loop of a process just stopped may still be running, Start would do nothing
+		proc.Restart(true)
+		close(started)
+	}()
+	select {
+	case <-started:
+	case <-time.After(time.Until(deadline)):
+	}
+	state := waitProcessLeaveStates(proc, deadline, isStartingState)
+	if state == process.Running {
+		return newRPCTaskResult(proc, faults.Success, "OK")
+	}
+	if isStartingState(state) {
+		return newRPCTaskResult(proc, faults.Failed, "TIMEOUT")
+	}
+	return newRPCTaskResult(proc, faults.SpawnError, state.String())
+}
+
+// stop the process and wait until it is not running or the deadline is reached
+func stopProcessBefore(proc *process.Process, deadline time.Time) RPCTaskResult {
+	if !isRunningState(proc.GetState()) {
+		return newRPCTaskResult(proc, faults.NotRunning, "NOT_RUNNING")
+	}
+	proc.Stop(false)
+	if isStoppingState(waitProcessLeaveStates(proc, deadline, isStoppingState)) {
+		return newRPCTaskResult(proc, faults.Failed, "TIMEOUT")
+	}
+	return newRPCTaskResult(proc, faults.Success, "OK")
+}
+
+// start the processes one by one, every process must be Running before starting the next one.
+// The processes not started before the deadline are reported as SKIPPED
+func startProcessesInOrder(procs []*process.Process, deadline time.Time) []RPCTaskResult {
+	results := make([]RPCTaskResult, 0)
+	for _, proc := range procs {
+		if !time.Now().Before(deadline) {
+			results = append(results, newRPCTaskResult(proc, faults.Failed, "SKIPPED"))
+			continue
+		}
+		result := startProcessBefore(proc, deadline)
+		log.WithFields(log.Fields{"program": proc.GetName(), "result": result.Description}).Info("start process in group")
+		results = append(results, result)
+	}
+	return results
+}
+
+// stop the processes one by one in the reverse order, every process must be stopped before
+// stopping the previous one. The processes not stopped before the deadline are reported as SKIPPED
+func stopProcessesInOrder(procs []*process.Process, deadline time.Time) []RPCTaskResult {
+	results := make([]RPCTaskResult, 0)
+	for i := len(procs) - 1; i >= 0; i-- {
+		proc := procs[i]
+		if !time.Now().Before(deadline) {
+			results = append(results, newRPCTaskResult(proc, faults.Failed, "SKIPPED"))
+			continue
+		}
+		result := stopProcessBefore(proc, deadline)
+		log.WithFields(log.Fields{"program": proc.GetName(), "result": result.Description}).Info("stop process in group")
+		results = append(results, result)
+	}
+	return results
+}
+
+// stop the processes in the reverse order and start them again in the order. The process
+// failed to stop is not started again
+func restartProcessesInOrder(procs []*process.Process, deadline time.Time) []RPCTaskResult {
+	failed := make(map[*process.Process]RPCTaskResult)
+	toStart := make([]*process.Process, 0)
+	stopResults := stopProcessesInOrder(procs, deadline)
+	for i, result := range stopResults {
+		proc := procs[len(procs)-1-i]
+		if result.Status == faults.Success || result.Status == faults.NotRunning {
+			toStart = append(toStart, proc)
+		} else {
+			failed[proc] = result
+		}
+	}
+	startResults := startProcessesInOrder(reverseProcesses(toStart), deadline)
+
+	results := make([]RPCTaskResult, 0)
+	for _, proc := range procs {
+		if result, ok := failed[proc]; ok {
+			results = append(results, result)
+		} else {
+			results = append(results, startResults[0])
+			startResults = startResults[1:]
+		}
+	}
+	return results
+}
+
+func reverseProcesses(procs []*process.Process) []*process.Process {
+	result := make([]*process.Process, 0, len(procs))
+	for i := len(procs) - 1; i >= 0; i-- {
+		result = append(result, procs[i])
+	}
+	return result
+}
+
+// run the start, stop or restart operation on the processes of a group. The processes are
+// started in the depends_on/priority order and stopped in the reverse order. If wait is
+// false, the operation is sent to every process without waiting for its state
+func runGroupOperation(procs []*process.Process, action string, wait bool, timeout time.Duration) []RPCTaskResult {
+	if !wait && action != "restart" {
+		results := make([]RPCTaskResult, 0)
+		if action == "stop" {
+			procs = reverseProcesses(procs)
+		}
+		for _, proc := range procs {
+			if action == "start" {
+				results = append(results, startProcess(proc, false))
+			} else {
+				results = append(results, stopProcess(proc, false))
+			}
+		}
+		return results
+	}
+
+	deadline := time.Now().Add(timeout)
+	switch action {
+	case "start":
+		return startProcessesInOrder(procs, deadline)
+	case "stop":
+		return stopProcessesInOrder(procs, deadline)
+	default:
+		return restartProcessesInOrder(procs, deadline)
+	}
+}
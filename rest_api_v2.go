@@ -0,0 +1,528 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/ochinchina/supervisord/faults"
+	"github.com/ochinchina/supervisord/process"
+	"github.com/ochinchina/supervisord/signals"
+	"github.com/ochinchina/supervisord/types"
+)
+
+// the OpenAPI document of the /api/v2 restful interface
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// APIv2 the resource oriented restful interface under /api/v2/
+type APIv2 struct {
+	router     *mux.Router
+	supervisor *Supervisor
+}
+
+// SupervisorInfo the information of supervisord
+type SupervisorInfo struct {
+	Version        string `json:"version"`
+	APIVersion     string `json:"api_version"`
+	Identification string `json:"identification"`
+	State          string `json:"state"`
+	Pid            int    `json:"pid"`
+}
+
+// ReloadResult the added, changed and removed groups and programs after reloading configuration
+type ReloadResult struct {
+	Added   []string `json:"added"`
+	Changed []string `json:"changed"`
+	Removed []string `json:"removed"`
+	// the added, changed and removed programs, the changed ones are restarted if running
+	AddedPrograms   []string `json:"added_programs"`
+	ChangedPrograms []string `json:"changed_programs"`
+	RemovedPrograms []string `json:"removed_programs"`
+}
+
+// GroupInfo the process group and the information of its processes
+type GroupInfo struct {
+	Name      string              `json:"name"`
+	Processes []types.ProcessInfo `json:"processes"`
+}
+
+// ProcessLogTail the result of tailing process log
+type ProcessLogTail struct {
+	Data     string `json:"data"`
+	Offset   int64  `json:"offset"`
+	Overflow bool   `json:"overflow"`
+}
+
+// SignalRequest the request to send a signal to processes
+type SignalRequest struct {
+	Signal string `json:"signal"`
+	// send the signal to the process group of the process
+	AsGroup bool `json:"as_group"`
+}
+
+// ScaleRequest the request to change the number of the instances of a program
+type ScaleRequest struct {
+	Numprocs int `json:"numprocs"`
+	// keep the numprocs across reloads and save it to numprocs_file
+	Persist bool `json:"persist"`
+}
+
+// AddProgramRequest the request to add a program at runtime
+type AddProgramRequest struct {
+	Name string `json:"name"`
+	// the settings of the [program:x] section
+	Config map[string]string `json:"config"`
+	// save the program to programs_dir so it is kept across reloads
+	Persist bool `json:"persist"`
+}
+
+// RollingRestartRequest the request to restart the instances of a program one batch at a time
+type RollingRestartRequest struct {
+	// the number of instances restarted at the same time, default is 1
+	Batch int `json:"batch"`
+	// the seconds to wait for every batch, default is 60
+	Timeout int `json:"timeout"`
+}
+
+// StdinRequest the request to write chars to the stdin of processes
+type StdinRequest struct {
+	Chars string `json:"chars"`
+}
+
+// NewAPIv2 creates an APIv2 object
+func NewAPIv2(supervisor *Supervisor) *APIv2 {
+	return &APIv2{router: mux.NewRouter(), supervisor: supervisor}
+}
+
+// CreateHandler creates http handler to process the /api/v2/ restful requests
+func (api *APIv2) CreateHandler() http.Handler {
+	// the log and event streaming are shared with the legacy interface
+	legacy := &SupervisorRestful{supervisor: api.supervisor}
+	r := api.router.PathPrefix("/api/v2").Subrouter()
+	r.HandleFunc("/openapi.json", api.getOpenAPIDocument).Methods("GET")
+	r.HandleFunc("/supervisor", api.getSupervisor).Methods("GET")
+	r.HandleFunc("/supervisor/reload", api.reload).Methods("POST")
+	r.HandleFunc("/supervisor/shutdown", api.shutdown).Methods("POST")
+	r.HandleFunc("/supervisor/events", legacy.StreamEvents).Methods("GET")
+	r.HandleFunc("/config", api.getEffectiveConfig).Methods("GET")
+	r.HandleFunc("/programs", api.listPrograms).Methods("GET")
+	r.HandleFunc("/programs", api.addProgram).Methods("POST")
+	r.HandleFunc("/programs/{name}", api.getProgram).Methods("GET")
+	r.HandleFunc("/programs/{name}", api.removeProgram).Methods("DELETE")
+	r.HandleFunc("/programs/{name}/conf", api.getProgramConfFile).Methods("GET")
+	r.HandleFunc("/programs/{name}/scale", api.scaleProgram).Methods("POST")
+	r.HandleFunc("/programs/{name}/rolling-restart", api.rollingRestartProgram).Methods("POST")
+	r.HandleFunc("/groups", api.listGroups).Methods("GET")
+	r.HandleFunc("/groups/{group}", api.getGroup).Methods("GET")
+	r.HandleFunc("/groups/{group}/{action:start|stop|restart}", api.groupAction).Methods("POST")
+	r.HandleFunc("/processes", api.listProcesses).Methods("GET")
+	r.HandleFunc("/processes/{name}", api.getProcess).Methods("GET")
+	r.HandleFunc("/processes/{name}/{action:start|stop|restart}", api.processAction).Methods("POST")
+	r.HandleFunc("/processes/{name}/signal", api.signalProcess).Methods("POST")
+	r.HandleFunc("/processes/{name}/reset-breaker", api.resetRestartBreaker).Methods("POST")
+	r.HandleFunc("/processes/{name}/stdin", api.sendProcessStdin).Methods("POST")
+	r.HandleFunc("/processes/{name}/logs/{stream:stdout|stderr}", api.readProcessLog).Methods("GET")
+	r.HandleFunc("/processes/{name}/logs/{stream:stdout|stderr}/tail", api.tailProcessLog).Methods("GET")
+	r.HandleFunc("/processes/{name}/logs/{stream:stdout|stderr}/stream", legacy.StreamLog).Methods("GET")
+	r.HandleFunc("/processes/{name}/logs/{stream:stdout|stderr}/ws", legacy.StreamLogWebSocket).Methods("GET")
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such resource %s", req.URL.Path))
+	})
+	return api.router
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// decode the json request body to v
+func readJSONBody(w http.ResponseWriter, req *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(req.Body).Decode(v); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("not a valid json request: %v", err))
+		return false
+	}
+	return true
+}
+
+func (api *APIv2) getOpenAPIDocument(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPIDocument)
+}
+
+func (api *APIv2) getSupervisor(w http.ResponseWriter, _ *http.Request) {
+	state := "RUNNING"
+	if api.supervisor.IsRestarting() {
+		state = "RESTARTING"
+	}
+	writeJSON(w, http.StatusOK, SupervisorInfo{
+		Version:        VERSION,
+		APIVersion:     SupervisorVersion,
+		Identification: api.supervisor.GetSupervisorID(),
+		State:          state,
+		Pid:            os.Getpid(),
+	})
+}
+
+func (api *APIv2) reload(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	result, err := api.supervisor.reload(false)
+	if err != nil {
+		writeFault(w, faults.NewFault(faults.CantReRead, err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, ReloadResult{
+		Added:   append(make([]string, 0), result.AddedGroup...),
+		Changed: append(make([]string, 0), result.ChangedGroup...),
+		Removed: append(make([]string, 0), result.RemovedGroup...),
+
+		AddedPrograms:   append(make([]string, 0), result.AddedProgram...),
+		ChangedPrograms: append(make([]string, 0), result.ChangedProgram...),
+		RemovedPrograms: append(make([]string, 0), result.RemovedProgram...),
+	})
+}
+
+func (api *APIv2) shutdown(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	reply := struct{ Ret bool }{false}
+	_ = api.supervisor.Shutdown(nil, nil, &reply)
+	writeJSON(w, http.StatusAccepted, map[string]bool{"success": reply.Ret})
+}
+
+func (api *APIv2) getAllConfigInfo() []ConfigInfo {
+	reply := struct{ ConfigInfo []ConfigInfo }{}
+	_ = api.supervisor.GetAllConfigInfo(nil, nil, &reply)
+	sort.Slice(reply.ConfigInfo, func(i, j int) bool {
+		return reply.ConfigInfo[i].Name < reply.ConfigInfo[j].Name
+	})
+	return reply.ConfigInfo
+}
+
+func (api *APIv2) listPrograms(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, api.getAllConfigInfo())
+}
+
+func (api *APIv2) getProgram(w http.ResponseWriter, req *http.Request) {
+	name := mux.Vars(req)["name"]
+	for _, info := range api.getAllConfigInfo() {
+		if info.Name == name {
+			writeJSON(w, http.StatusOK, info)
+			return
+		}
+	}
+	writeFault(w, faults.NewFault(faults.BadName, fmt.Sprintf("no program named %s", name)))
+}
+
+func (api *APIv2) getProgramConfFile(w http.ResponseWriter, req *http.Request) {
+	name := mux.Vars(req)["name"]
+	if api.supervisor.config.GetProgram(name) == nil {
+		writeFault(w, faults.NewFault(faults.BadName, fmt.Sprintf("no program named %s", name)))
+		return
+	}
+	programConfigPath := getProgramConfigPath(name, api.supervisor)
+	if programConfigPath == "" {
+		writeFault(w, faults.NewFault(faults.NoFile, fmt.Sprintf("no configuration file of program %s", name)))
+		return
+	}
+	b, err := readFile(programConfigPath)
+	if err != nil {
+		writeFault(w, faults.NewFault(faults.NoFile, err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write(b)
+}
+
+// getEffectiveConfig gets the effective configuration of the programs in json or ini format, the
+// programs or processes can be selected with the program query parameters
+func (api *APIv2) getEffectiveConfig(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "ini" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown format %s", format))
+		return
+	}
+	api.supervisor.lock.Lock()
+	programs := api.supervisor.config.GetEffectivePrograms(query["program"]...)
+	api.supervisor.lock.Unlock()
+	if len(query["program"]) > 0 && len(programs) == 0 {
+		writeFault(w, faults.NewFault(faults.BadName, fmt.Sprintf("no program named %s", strings.Join(query["program"], ", "))))
+		return
+	}
+	if format == "ini" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_ = writeEffectivePrograms(w, programs, format)
+		return
+	}
+	writeJSON(w, http.StatusOK, programs)
+}
+
+// addProgram adds a program defined by the settings of a [program:x] section
+func (api *APIv2) addProgram(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	var addReq AddProgramRequest
+	if !readJSONBody(w, req, &addReq) {
+		return
+	}
+	result, err := api.supervisor.addProgram(addReq.Name, addReq.Config, addReq.Persist)
+	if err != nil {
+		writeFault(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// removeProgram stops the processes of the program and removes it
+func (api *APIv2) removeProgram(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	result, err := api.supervisor.removeProgram(mux.Vars(req)["name"])
+	if err != nil {
+		writeFault(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// scaleProgram changes the number of the instances of the program
+func (api *APIv2) scaleProgram(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	var scaleReq ScaleRequest
+	if !readJSONBody(w, req, &scaleReq) {
+		return
+	}
+	result, err := api.supervisor.scaleProgram(mux.Vars(req)["name"], scaleReq.Numprocs, scaleReq.Persist)
+	if err != nil {
+		writeFault(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// rollingRestartProgram restarts the instances of the program one batch at a time, the restart is
+// aborted if an instance fails to become Running and healthy
+func (api *APIv2) rollingRestartProgram(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	var restartReq RollingRestartRequest
+	if !readJSONBody(w, req, &restartReq) {
+		return
+	}
+	results, err := api.supervisor.rollingRestart(mux.Vars(req)["name"], restartReq.Batch, rollingRestartTimeout(restartReq.Timeout))
+	if err != nil {
+		writeFault(w, err)
+		return
+	}
+	writeResults(w, results)
+}
+
+func (api *APIv2) getAllProcessInfo() []types.ProcessInfo {
+	reply := struct{ AllProcessInfo []types.ProcessInfo }{}
+	_ = api.supervisor.GetAllProcessInfo(nil, nil, &reply)
+	return reply.AllProcessInfo
+}
+
+// get all the groups sorted by name
+func (api *APIv2) getGroups() []GroupInfo {
+	groups := make([]GroupInfo, 0)
+	index := make(map[string]int)
+	for _, info := range api.getAllProcessInfo() {
+		i, ok := index[info.Group]
+		if !ok {
+			i = len(groups)
+			index[info.Group] = i
+			groups = append(groups, GroupInfo{Name: info.Group, Processes: make([]types.ProcessInfo, 0)})
+		}
+		groups[i].Processes = append(groups[i].Processes, info)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+func (api *APIv2) listGroups(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, api.getGroups())
+}
+
+func (api *APIv2) getGroup(w http.ResponseWriter, req *http.Request) {
+	name := mux.Vars(req)["group"]
+	for _, group := range api.getGroups() {
+		if group.Name == name {
+			writeJSON(w, http.StatusOK, group)
+			return
+		}
+	}
+	writeFault(w, faults.NewFault(faults.BadName, fmt.Sprintf("no group named %s", name)))
+}
+
+// call the action on all the processes and write the results
+func writeActionResults(w http.ResponseWriter, procs []*process.Process, action string) {
+	results := make([]RPCTaskResult, 0)
+	for _, proc := range procs {
+		switch action {
+		case "start":
+			results = append(results, startProcess(proc, true))
+		case "stop":
+			results = append(results, stopProcess(proc, true))
+		case "restart":
+			results = append(results, restartProcess(proc))
+		}
+	}
+	writeResults(w, results)
+}
+
+// groupAction starts the processes of the group in the depends_on/priority order, or stops
+// them in the reverse order. Every process must reach Running or Stopped before handling the
+// next one, the whole group must be handled in "timeout" seconds
+func (api *APIv2) groupAction(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	params := mux.Vars(req)
+	timeout, err := getIntQuery(req, "timeout", 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	procs := api.supervisor.procMgr.FindGroup(params["group"])
+	if len(procs) == 0 {
+		writeFault(w, faults.NewFault(faults.BadName, fmt.Sprintf("no group named %s", params["group"])))
+		return
+	}
+	writeResults(w, runGroupOperation(procs, params["action"], true, groupOperationTimeout(timeout)))
+}
+
+func (api *APIv2) listProcesses(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, api.getAllProcessInfo())
+}
+
+// find the processes by the name which can be "name", "group:name" or "group:*"
+func (api *APIv2) findProcesses(w http.ResponseWriter, req *http.Request) ([]*process.Process, bool) {
+	name := mux.Vars(req)["name"]
+	procs := api.supervisor.procMgr.FindMatch(name)
+	if len(procs) == 0 {
+		writeFault(w, faults.NewFault(faults.BadName, fmt.Sprintf("no process named %s", name)))
+		return nil, false
+	}
+	sort.Slice(procs, func(i, j int) bool {
+		return procs[i].GetName() < procs[j].GetName()
+	})
+	return procs, true
+}
+
+func (api *APIv2) getProcess(w http.ResponseWriter, req *http.Request) {
+	procs, ok := api.findProcesses(w, req)
+	if !ok {
+		return
+	}
+	if len(procs) > 1 {
+		writeFault(w, faults.NewFault(faults.BadArguments, fmt.Sprintf("%s matches more than one process", mux.Vars(req)["name"])))
+		return
+	}
+	writeJSON(w, http.StatusOK, getProcessInfo(procs[0]))
+}
+
+func (api *APIv2) processAction(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	procs, ok := api.findProcesses(w, req)
+	if !ok {
+		return
+	}
+	writeActionResults(w, procs, mux.Vars(req)["action"])
+}
+
+func (api *APIv2) signalProcess(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	var signalReq SignalRequest
+	if !readJSONBody(w, req, &signalReq) {
+		return
+	}
+	sig, err := signals.ParseSignal(signalReq.Signal)
+	if err != nil {
+		writeFault(w, faults.NewFault(faults.BadSignal, err.Error()))
+		return
+	}
+	procs, ok := api.findProcesses(w, req)
+	if !ok {
+		return
+	}
+	results := make([]RPCTaskResult, 0)
+	for _, proc := range procs {
+		results = append(results, signalProcess(proc, sig, signalReq.AsGroup))
+	}
+	writeResults(w, results)
+}
+
+// resetRestartBreaker clears the restart history of the processes so they are restarted
+// automatically again after exceeding their restart limit
+func (api *APIv2) resetRestartBreaker(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	procs, ok := api.findProcesses(w, req)
+	if !ok {
+		return
+	}
+	results := make([]RPCTaskResult, 0)
+	for _, proc := range procs {
+		results = append(results, resetRestartBreaker(proc))
+	}
+	writeResults(w, results)
+}
+
+func (api *APIv2) sendProcessStdin(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	var stdinReq StdinRequest
+	if !readJSONBody(w, req, &stdinReq) {
+		return
+	}
+	procs, ok := api.findProcesses(w, req)
+	if !ok {
+		return
+	}
+	results := make([]RPCTaskResult, 0)
+	for _, proc := range procs {
+		results = append(results, sendProcessStdin(proc, stdinReq.Chars))
+	}
+	writeResults(w, results)
+}
+
+func (api *APIv2) readProcessLog(w http.ResponseWriter, req *http.Request) {
+	legacy := &SupervisorRestful{supervisor: api.supervisor}
+	legacy.readLog(w, req, mux.Vars(req)["stream"] == "stderr")
+}
+
+func (api *APIv2) tailProcessLog(w http.ResponseWriter, req *http.Request) {
+	length, err := getIntQuery(req, "length", defaultTailLogLength)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	offset, err := getIntQuery(req, "offset", -1)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	params := mux.Vars(req)
+	data, nextOffset, overflow, err := api.supervisor.tailLog(params["name"], params["stream"] == "stderr", offset, length)
+	if err != nil {
+		writeFault(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ProcessLogTail{Data: data, Offset: nextOffset, Overflow: overflow})
+}
@@ -2,10 +2,16 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
+	xmlrpc "github.com/ochinchina/gorilla-xmlrpc/xml"
+	"github.com/ochinchina/supervisord/faults"
+	"github.com/ochinchina/supervisord/process"
+	"github.com/ochinchina/supervisord/signals"
 	"github.com/ochinchina/supervisord/types"
 )
 
@@ -25,7 +31,15 @@ func (sr *SupervisorRestful) CreateProgramHandler() http.Handler {
 	sr.router.HandleFunc("/program/list", sr.ListProgram).Methods("GET")
 	sr.router.HandleFunc("/program/start/{name}", sr.StartProgram).Methods("POST", "PUT")
 	sr.router.HandleFunc("/program/stop/{name}", sr.StopProgram).Methods("POST", "PUT")
+	sr.router.HandleFunc("/program/restart/{name}", sr.RestartProgram).Methods("POST", "PUT")
+	sr.router.HandleFunc("/program/signal/{name}", sr.SignalProgram).Methods("POST", "PUT")
+	sr.router.HandleFunc("/program/stdin/{name}", sr.SendProgramStdin).Methods("POST", "PUT")
 	sr.router.HandleFunc("/program/log/{name}/stdout", sr.ReadStdoutLog).Methods("GET")
+	sr.router.HandleFunc("/program/log/{name}/stderr", sr.ReadStderrLog).Methods("GET")
+	sr.router.HandleFunc("/program/log/{name}/stdout/tail", sr.TailStdoutLog).Methods("GET")
+	sr.router.HandleFunc("/program/log/{name}/stderr/tail", sr.TailStderrLog).Methods("GET")
+	sr.router.HandleFunc("/program/log/{name}/{stream:stdout|stderr}/stream", sr.StreamLog).Methods("GET")
+	sr.router.HandleFunc("/program/log/{name}/{stream:stdout|stderr}/ws", sr.StreamLogWebSocket).Methods("GET")
 	sr.router.HandleFunc("/program/startPrograms", sr.StartPrograms).Methods("POST", "PUT")
 	sr.router.HandleFunc("/program/stopPrograms", sr.StopPrograms).Methods("POST", "PUT")
 	return sr.router
@@ -44,112 +58,264 @@ func (sr *SupervisorRestful) CreateSupervisorHandler() http.Handler {
 func (sr *SupervisorRestful) ListProgram(w http.ResponseWriter, _ *http.Request) {
 	result := struct{ AllProcessInfo []types.ProcessInfo }{make([]types.ProcessInfo, 0)}
 
-	sr.supervisor.GetAllProcessInfo(nil, nil, &result)
+	_ = sr.supervisor.GetAllProcessInfo(nil, nil, &result)
 
 	_ = json.NewEncoder(w).Encode(result.AllProcessInfo)
 }
 
 // StartProgram start the given program through restful interface
+//
+// json array of RPCTaskResult to present the result of every process
 func (sr *SupervisorRestful) StartProgram(w http.ResponseWriter, req *http.Request) {
 	defer req.Body.Close()
-	params := mux.Vars(req)
-	success, err := sr._startProgram(params["name"])
-	r := map[string]bool{"success": err == nil && success}
-	_ = json.NewEncoder(w).Encode(&r)
-}
 
-func (sr *SupervisorRestful) _startProgram(program string) (bool, error) {
-	startArgs := StartProcessArgs{Name: program, Wait: true}
-	result := struct{ Success bool }{false}
-	err := sr.supervisor.StartProcess(nil, &startArgs, &result)
-	return result.Success, err
+	procs, ok := sr.findMatchedProcesses(w, req)
+	if !ok {
+		return
+	}
+	results := make([]RPCTaskResult, 0)
+	for _, proc := range procs {
+		results = append(results, startProcess(proc, true))
+	}
+	writeResults(w, results)
 }
 
 // StartPrograms start one or more programs through restful interface
+//
+// the request body is a json array of program names, json array of RPCTaskResult
+// to present the result of every process
 func (sr *SupervisorRestful) StartPrograms(w http.ResponseWriter, req *http.Request) {
 	defer req.Body.Close()
-	var b []byte
-	var err error
 
-	if b, err = io.ReadAll(req.Body); err != nil {
-		w.WriteHeader(400)
-		_, _ = w.Write([]byte("not a valid request"))
+	programs, ok := readProgramNames(w, req)
+	if !ok {
 		return
 	}
-
-	var programs []string
-	if err = json.Unmarshal(b, &programs); err != nil {
-		w.WriteHeader(400)
-		_, _ = w.Write([]byte("not a valid request"))
-	} else {
-		successStarts := 0
-		for _, program := range programs {
-			success, err := sr._startProgram(program)
-			if success && err == nil {
-				successStarts++
-			}
-		}
-
-		if successStarts != len(programs) {
-			_, _ = w.Write([]byte("Failed to start the programs"))
-		} else {
-			_, _ = w.Write([]byte("Success to start the programs"))
-		}
-	}
+	writeResults(w, sr.forEachProgram(programs, func(proc *process.Process) RPCTaskResult {
+		return startProcess(proc, true)
+	}))
 }
 
 // StopProgram stop a program through the restful interface
+//
+// json array of RPCTaskResult to present the result of every process
 func (sr *SupervisorRestful) StopProgram(w http.ResponseWriter, req *http.Request) {
 	defer req.Body.Close()
 
-	params := mux.Vars(req)
-	success, err := sr._stopProgram(params["name"])
-	r := map[string]bool{"success": err == nil && success}
-	_ = json.NewEncoder(w).Encode(&r)
-}
-
-func (sr *SupervisorRestful) _stopProgram(programName string) (bool, error) {
-	stopArgs := StartProcessArgs{Name: programName, Wait: true}
-	result := struct{ Success bool }{false}
-	err := sr.supervisor.StopProcess(nil, &stopArgs, &result)
-	return result.Success, err
+	procs, ok := sr.findMatchedProcesses(w, req)
+	if !ok {
+		return
+	}
+	results := make([]RPCTaskResult, 0)
+	for _, proc := range procs {
+		results = append(results, stopProcess(proc, true))
+	}
+	writeResults(w, results)
 }
 
 // StopPrograms stop programs through the restful interface
+//
+// the request body is a json array of program names, json array of RPCTaskResult
+// to present the result of every process
 func (sr *SupervisorRestful) StopPrograms(w http.ResponseWriter, req *http.Request) {
 	defer req.Body.Close()
 
-	var programs []string
-	var b []byte
-	var err error
-	if b, err = io.ReadAll(req.Body); err != nil {
-		w.WriteHeader(400)
-		_, _ = w.Write([]byte("not a valid request"))
+	programs, ok := readProgramNames(w, req)
+	if !ok {
 		return
 	}
+	writeResults(w, sr.forEachProgram(programs, func(proc *process.Process) RPCTaskResult {
+		return stopProcess(proc, true)
+	}))
+}
 
-	if err := json.Unmarshal(b, &programs); err != nil {
-		w.WriteHeader(400)
-		_, _ = w.Write([]byte("not a valid request"))
-	} else {
-		successStops := 0
-		for _, program := range programs {
-			success, err := sr._stopProgram(program)
-			if success && err == nil {
-				successStops++
-			}
+// read the json array of program names from request body
+func readProgramNames(w http.ResponseWriter, req *http.Request) ([]string, bool) {
+	var programs []string
+	b, err := io.ReadAll(req.Body)
+	if err == nil {
+		err = json.Unmarshal(b, &programs)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("not a valid request, a json array of program names is expected"))
+		return nil, false
+	}
+	return programs, true
+}
+
+// call the taskFunc for every process matched by the programs. A BadName result is
+// added for the program which matches no process
+func (sr *SupervisorRestful) forEachProgram(programs []string, taskFunc func(proc *process.Process) RPCTaskResult) []RPCTaskResult {
+	results := make([]RPCTaskResult, 0)
+	for _, program := range programs {
+		procs := sr.supervisor.procMgr.FindMatch(program)
+		if len(procs) == 0 {
+			results = append(results, RPCTaskResult{Name: program, Status: faults.BadName, Description: "BAD_NAME"})
 		}
+		for _, proc := range procs {
+			results = append(results, taskFunc(proc))
+		}
+	}
+	return results
+}
+
+// find the processes matched by the name in the request, "group:*" and "group:program" are accepted
+func (sr *SupervisorRestful) findMatchedProcesses(w http.ResponseWriter, req *http.Request) ([]*process.Process, bool) {
+	name := mux.Vars(req)["name"]
+	procs := sr.supervisor.procMgr.FindMatch(name)
+	if len(procs) == 0 {
+		writeFault(w, faults.NewFault(faults.BadName, fmt.Sprintf("fail to find process %s", name)))
+		return nil, false
+	}
+	return procs, true
+}
+
+// RestartProgram restart the given programs through restful interface
+//
+// json array of RPCTaskResult to present the result of every process
+func (sr *SupervisorRestful) RestartProgram(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	procs, ok := sr.findMatchedProcesses(w, req)
+	if !ok {
+		return
+	}
+	results := make([]RPCTaskResult, 0)
+	for _, proc := range procs {
+		results = append(results, restartProcess(proc))
+	}
+	writeResults(w, results)
+}
 
-		if successStops != len(programs) {
-			_, _ = w.Write([]byte("Failed to stop the programs"))
-		} else {
-			_, _ = w.Write([]byte("Success to stop the programs"))
+// SignalProgram send a signal to the given programs through restful interface
+//
+// the query parameter "signal" is the signal name like "HUP" or number, the signal is sent to
+// the process group of the program if the query parameter "asgroup" is true.
+// json array of RPCTaskResult to present the result of every process
+func (sr *SupervisorRestful) SignalProgram(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	sig, err := signals.ParseSignal(req.URL.Query().Get("signal"))
+	if err != nil {
+		writeFault(w, faults.NewFault(faults.BadSignal, err.Error()))
+		return
+	}
+	asGroup := false
+	if value := req.URL.Query().Get("asgroup"); value != "" {
+		if asGroup, err = strconv.ParseBool(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid query parameter asgroup: %s", value))
+			return
 		}
 	}
+	procs, ok := sr.findMatchedProcesses(w, req)
+	if !ok {
+		return
+	}
+	results := make([]RPCTaskResult, 0)
+	for _, proc := range procs {
+		results = append(results, signalProcess(proc, sig, asGroup))
+	}
+	writeResults(w, results)
+}
+
+// SendProgramStdin write the request body to the stdin of the given programs through restful interface
+//
+// json array of RPCTaskResult to present the result of every process
+func (sr *SupervisorRestful) SendProgramStdin(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("not a valid request"))
+		return
+	}
+	procs, ok := sr.findMatchedProcesses(w, req)
+	if !ok {
+		return
+	}
+	results := make([]RPCTaskResult, 0)
+	for _, proc := range procs {
+		results = append(results, sendProcessStdin(proc, string(b)))
+	}
+	writeResults(w, results)
 }
 
 // ReadStdoutLog read the stdout of given program
-func (sr *SupervisorRestful) ReadStdoutLog(_ http.ResponseWriter, _ *http.Request) {
+//
+// the query parameters "offset" and "length" have same meaning as the xml rpc readProcessStdoutLog
+func (sr *SupervisorRestful) ReadStdoutLog(w http.ResponseWriter, req *http.Request) {
+	sr.readLog(w, req, false)
+}
+
+// ReadStderrLog read the stderr of given program
+//
+// the query parameters "offset" and "length" have same meaning as the xml rpc readProcessStderrLog
+func (sr *SupervisorRestful) ReadStderrLog(w http.ResponseWriter, req *http.Request) {
+	sr.readLog(w, req, true)
+}
+
+func (sr *SupervisorRestful) readLog(w http.ResponseWriter, req *http.Request, stderr bool) {
+	defer req.Body.Close()
+
+	offset, err := getIntQuery(req, "offset", 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	length, err := getIntQuery(req, "length", 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	procLogger, err := sr.supervisor.getProcessLogger(mux.Vars(req)["name"], stderr)
+	if err != nil {
+		writeFault(w, err)
+		return
+	}
+	data, err := procLogger.ReadLog(int64(offset), int64(length))
+	if err != nil {
+		writeFault(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(data))
+}
+
+// TailStdoutLog tail the stdout of given program
+//
+// read at most "length" bytes from "offset", the last "length" bytes are returned if "offset" is not provided.
+// The returned json object includes the log, the offset for next reading and the overflow flag
+func (sr *SupervisorRestful) TailStdoutLog(w http.ResponseWriter, req *http.Request) {
+	sr.tailLog(w, req, false)
+}
+
+// TailStderrLog tail the stderr of given program
+//
+// read at most "length" bytes from "offset", the last "length" bytes are returned if "offset" is not provided.
+// The returned json object includes the log, the offset for next reading and the overflow flag
+func (sr *SupervisorRestful) TailStderrLog(w http.ResponseWriter, req *http.Request) {
+	sr.tailLog(w, req, true)
+}
+
+func (sr *SupervisorRestful) tailLog(w http.ResponseWriter, req *http.Request, stderr bool) {
+	defer req.Body.Close()
+
+	length, err := getIntQuery(req, "length", defaultTailLogLength)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	offset, err := getIntQuery(req, "offset", -1)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	data, nextOffset, overflow, err := sr.supervisor.tailLog(mux.Vars(req)["name"], stderr, offset, length)
+	if err != nil {
+		writeFault(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(ProcessTailLog{LogData: data, Offset: int(nextOffset), Overflow: overflow})
 }
 
 // Shutdown the supervisor itself
@@ -157,7 +323,7 @@ func (sr *SupervisorRestful) Shutdown(w http.ResponseWriter, req *http.Request)
 	defer req.Body.Close()
 
 	reply := struct{ Ret bool }{false}
-	sr.supervisor.Shutdown(nil, nil, &reply)
+	_ = sr.supervisor.Shutdown(nil, nil, &reply)
 	_, _ = w.Write([]byte("Shutdown..."))
 }
 
@@ -165,14 +331,102 @@ func (sr *SupervisorRestful) Shutdown(w http.ResponseWriter, req *http.Request)
 func (sr *SupervisorRestful) Reload(w http.ResponseWriter, req *http.Request) {
 	defer req.Body.Close()
 
-	reply := struct{ Ret bool }{false}
 	err := sr.supervisor.Reload(false)
 	if err != nil {
-		r := map[string]bool{"success": false}
-		_ = json.NewEncoder(w).Encode(&r)
+		writeFault(w, faults.NewFault(faults.CantReRead, err.Error()))
 		return
 	}
 
-	r := map[string]bool{"success": reply.Ret}
+	r := map[string]bool{"success": true}
 	_ = json.NewEncoder(w).Encode(&r)
 }
+
+// get the integer query parameter, the defValue is returned if the parameter is not provided
+func getIntQuery(req *http.Request, name string, defValue int) (int, error) {
+	value := req.URL.Query().Get(name)
+	if value == "" {
+		return defValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid query parameter %s: %s", name, value)
+	}
+	return n, nil
+}
+
+// restError the json body of all the failed restful requests
+type restError struct {
+	// the fault code defined in faults package
+	Code int `json:"code"`
+	// the description of the error
+	Message string `json:"message"`
+}
+
+// get the http status code for the fault code
+func faultStatusCode(code int) int {
+	switch code {
+	case faults.Success:
+		return http.StatusOK
+	case faults.BadName, faults.NoFile:
+		return http.StatusNotFound
+	case faults.BadArguments, faults.IncorrectParameters, faults.BadSignal:
+		return http.StatusBadRequest
+	case faults.AlreadyStated, faults.NotRunning, faults.AlreadyAdded, faults.StillRunning:
+		return http.StatusConflict
+	case faults.ShutdownState:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// get the fault code for the http status code of the error not raised by a fault
+func statusFaultCode(statusCode int) int {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return faults.BadArguments
+	case http.StatusNotFound:
+		return faults.BadName
+	}
+	return faults.Failed
+}
+
+// write the error with http status code in json format
+func writeError(w http.ResponseWriter, statusCode int, err error) {
+	body := restError{Code: statusFaultCode(statusCode), Message: err.Error()}
+	if fault, ok := err.(xmlrpc.Fault); ok {
+		body = restError{Code: fault.Code, Message: fault.String}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// write the xml rpc fault with the http status code mapped from the fault code
+func writeFault(w http.ResponseWriter, err error) {
+	statusCode := http.StatusInternalServerError
+	if fault, ok := err.(xmlrpc.Fault); ok {
+		statusCode = faultStatusCode(fault.Code)
+	}
+	writeError(w, statusCode, err)
+}
+
+// write the results of processes in json format. The http status code is 200 if all the
+// processes succeed, the status code mapped from the fault code if all the failed processes
+// have same fault code, otherwise 207 (multi-status)
+func writeResults(w http.ResponseWriter, results []RPCTaskResult) {
+	statuses := make(map[int]bool)
+	for _, result := range results {
+		statuses[result.Status] = true
+	}
+	statusCode := http.StatusOK
+	if len(statuses) > 1 {
+		statusCode = http.StatusMultiStatus
+	} else {
+		for status := range statuses {
+			statusCode = faultStatusCode(status)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ochinchina/supervisord/faults"
+	"github.com/ochinchina/supervisord/process"
+	log "github.com/sirupsen/logrus"
+)
+
+// the default seconds to wait for an instance to be stopped and Running again in a rolling restart
+const defaultRollingRestartTimeout = 60
+
+// RollingRestartArgs arguments for restarting the instances of a program one batch at a time
+type RollingRestartArgs struct {
+	Name    string // the program name in [program:x] section
+	Batch   int    // the number of instances restarted at the same time, 0 for 1
+	Timeout int    // the seconds to wait for every batch, 0 for defaultRollingRestartTimeout
+}
+
+// get the timeout of every batch from the seconds in the request
+func rollingRestartTimeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		seconds = defaultRollingRestartTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// the programs being restarted one by one
+var rollingRestarts = struct {
+	sync.Mutex
+	programs map[string]bool
+}{programs: make(map[string]bool)}
+
+// restart the process and wait until it is Running and healthy, or fails
+func restartProcessBefore(proc *process.Process, deadline time.Time) RPCTaskResult {
+	if isRunningState(proc.GetState()) {
+		if result := stopProcessBefore(proc, deadline); result.Status != faults.Success {
+			return result
+		}
+	}
+	result := startProcessBefore(proc, deadline)
+	if result.Status != faults.Success {
+		return result
+	}
+	// wait for the health check if the process has one
+	for !proc.IsReady() {
+		if proc.GetState() != process.Running {
+			return newRPCTaskResult(proc, faults.SpawnError, proc.GetState().String())
+		}
+		if proc.GetHealth() == process.HealthUnhealthy {
+			return newRPCTaskResult(proc, faults.Failed, "UNHEALTHY")
+		}
+		if !time.Now().Before(deadline) {
+			return newRPCTaskResult(proc, faults.Failed, "TIMEOUT")
+		}
+		time.Sleep(groupOperationPollingTime)
+	}
+	return result
+}
+
+// restart the processes batch by batch, every process of a batch must be Running and healthy
+// before restarting the next batch. After a process fails, the rest are reported as SKIPPED
+func rollingRestartProcesses(procs []*process.Process, batch int, timeout time.Duration) []RPCTaskResult {
+	results := make([]RPCTaskResult, 0, len(procs))
+	failed := false
+	for start := 0; start < len(procs); start += batch {
+		batchProcs := procs[start:min(start+batch, len(procs))]
+		if failed {
+			for _, proc := range batchProcs {
+				results = append(results, newRPCTaskResult(proc, faults.Failed, "SKIPPED"))
+			}
+			continue
+		}
+		deadline := time.Now().Add(timeout)
+		batchResults := make([]RPCTaskResult, len(batchProcs))
+		var wg sync.WaitGroup
+		for i, proc := range batchProcs {
+			wg.Add(1)
+			go func(i int, proc *process.Process) {
+				defer wg.Done()
+				batchResults[i] = restartProcessBefore(proc, deadline)
+			}(i, proc)
+		}
+		wg.Wait()
+		for i, result := range batchResults {
+			log.WithFields(log.Fields{"program": batchProcs[i].GetName(), "result": result.Description}).Info("rolling restart process")
+			if result.Status != faults.Success {
+				failed = true
+			}
+		}
+		results = append(results, batchResults...)
+	}
+	if failed {
+		log.Error("rolling restart is aborted because a process fails to restart")
+	}
+	return results
+}
+
+// mark the program being restarted one by one, returns false if it is being restarted already
+func claimRollingRestart(programName string) bool {
+	rollingRestarts.Lock()
+	defer rollingRestarts.Unlock()
+	if rollingRestarts.programs[programName] {
+		return false
+	}
+	rollingRestarts.programs[programName] = true
+	return true
+}
+
+func releaseRollingRestart(programName string) {
+	rollingRestarts.Lock()
+	defer rollingRestarts.Unlock()
+	delete(rollingRestarts.programs, programName)
+}
+
+// restart the instances of the program batch by batch in the order of process_num
+func (s *Supervisor) rollingRestart(programName string, batch int, timeout time.Duration) ([]RPCTaskResult, error) {
+	if !claimRollingRestart(programName) {
+		return nil, faults.NewFault(faults.StillRunning, fmt.Sprintf("program %s is being restarted", programName))
+	}
+	defer releaseRollingRestart(programName)
+	return s.runRollingRestart(programName, batch, timeout)
+}
+
+// restart the instances of the program claimed by claimRollingRestart
+func (s *Supervisor) runRollingRestart(programName string, batch int, timeout time.Duration) ([]RPCTaskResult, error) {
+	s.lock.Lock()
+	names := s.config.GetProgramInstanceNames(programName)
+	_, ok := s.config.GetNumprocs(programName)
+	s.lock.Unlock()
+	if !ok {
+		return nil, faults.NewFault(faults.BadName, fmt.Sprintf("no program named %s", programName))
+	}
+	procs := make([]*process.Process, 0, len(names))
+	for _, name := range names {
+		if proc := s.procMgr.Find(name); proc != nil {
+			procs = append(procs, proc)
+		}
+	}
+	if batch <= 0 {
+		batch = 1
+	}
+	log.WithFields(log.Fields{"program": programName, "instances": len(procs), "batch": batch}).Info("rolling restart program")
+	return rollingRestartProcesses(procs, batch, timeout), nil
+}
+
+// rolling restart the program of the process when its binary is changed. The batch size and the
+// timeout are rolling_restart_batch and rolling_restart_timeout in [program:x] section
+func (s *Supervisor) rollingRestartOnBinaryChange(proc *process.Process) {
+	s.lock.Lock()
+	programName, ok := s.config.GetProgramOfProcess(proc.GetName())
+	entry := s.config.GetProgramSection(programName)
+	s.lock.Unlock()
+	// every instance is notified of the change, only the first one restarts the program
+	if !ok || entry == nil || !claimRollingRestart(programName) {
+		return
+	}
+	batch := entry.GetInt("rolling_restart_batch", 1)
+	timeout := rollingRestartTimeout(entry.GetInt("rolling_restart_timeout", 0))
+	go func() {
+		defer releaseRollingRestart(programName)
+		if _, err := s.runRollingRestart(programName, batch, timeout); err != nil {
+			log.WithFields(log.Fields{"program": programName, log.ErrorKey: err}).Warn("fail to rolling restart program")
+		}
+	}()
+}
+
+// RollingRestartProgram restarts the instances of the program one batch at a time, every batch must
+// be Running and healthy before restarting the next one. The restart is aborted if an instance fails
+func (s *Supervisor) RollingRestartProgram(_ *http.Request, args *RollingRestartArgs, reply *struct{ RPCTaskResults []RPCTaskResult }) error {
+	results, err := s.rollingRestart(args.Name, args.Batch, rollingRestartTimeout(args.Timeout))
+	if err != nil {
+		return err
+	}
+	reply.RPCTaskResults = results
+	return nil
+}
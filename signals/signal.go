@@ -5,6 +5,7 @@ package signals
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"syscall"
 )
@@ -47,12 +48,30 @@ var signalMap = map[string]os.Signal{
 	"SIGXFSZ":   syscall.SIGXFSZ,
 }
 
+// ParseSignal returns OS dependent signal for given signal name like "HUP", "SIGHUP" or
+// signal number like "1", error is returned if garbage given
+func ParseSignal(signalName string) (os.Signal, error) {
+	if n, err := strconv.Atoi(signalName); err == nil {
+		for _, sig := range signalMap {
+			if int(sig.(syscall.Signal)) == n {
+				return sig, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid signal number %d", n)
+	}
+	name := strings.ToUpper(signalName)
+	if !strings.HasPrefix(name, "SIG") {
+		name = fmt.Sprintf("SIG%s", name)
+	}
+	if sig, ok := signalMap[name]; ok {
+		return sig, nil
+	}
+	return nil, fmt.Errorf("invalid signal name %s", signalName)
+}
+
 // ToSignal returns OS dependent signal name for given signal name (or syscall.SIGTERM if garbage given)
 func ToSignal(signalName string) (os.Signal, error) {
-	if !strings.HasPrefix(signalName, "SIG") {
-		signalName = fmt.Sprintf("SIG%s", signalName)
-	}
-	if sig, ok := signalMap[signalName]; ok {
+	if sig, err := ParseSignal(signalName); err == nil {
 		return sig, nil
 	}
 	return syscall.SIGTERM, nil
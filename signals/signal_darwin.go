@@ -5,6 +5,7 @@ package signals
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"syscall"
 )
@@ -44,12 +45,30 @@ var signalMap = map[string]os.Signal{
 	"SIGXFSZ":   syscall.SIGXFSZ,
 }
 
-// ToSignal convert a signal name to signal
-func ToSignal(signalName string) (os.Signal, error) {
-	if !strings.HasPrefix(signalName, "SIG") {
-		signalName = fmt.Sprintf("SIG%s", signalName)
+// ParseSignal convert a signal name like "HUP", "SIGHUP" or signal number like "1"
+// to signal, error is returned if garbage given
+func ParseSignal(signalName string) (os.Signal, error) {
+	if n, err := strconv.Atoi(signalName); err == nil {
+		for _, sig := range signalMap {
+			if int(sig.(syscall.Signal)) == n {
+				return sig, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid signal number %d", n)
+	}
+	name := strings.ToUpper(signalName)
+	if !strings.HasPrefix(name, "SIG") {
+		name = fmt.Sprintf("SIG%s", name)
+	}
+	if sig, ok := signalMap[name]; ok {
+		return sig, nil
 	}
-	if sig, ok := signalMap[signalName]; ok {
+	return nil, fmt.Errorf("invalid signal name %s", signalName)
+}
+
+// ToSignal convert a signal name to signal, syscall.SIGTERM is returned if garbage given
+func ToSignal(signalName string) (os.Signal, error) {
+	if sig, err := ParseSignal(signalName); err == nil {
 		return sig, nil
 	}
 	return syscall.SIGTERM, nil
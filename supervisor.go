@@ -2,15 +2,20 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/ochinchina/supervisord/config"
+	"github.com/ochinchina/supervisord/events"
+	"github.com/ochinchina/supervisord/faults"
 	"github.com/ochinchina/supervisord/logger"
 	"github.com/ochinchina/supervisord/process"
+	"github.com/ochinchina/supervisord/signals"
 	"github.com/ochinchina/supervisord/types"
 	"github.com/ochinchina/supervisord/util"
 
@@ -31,6 +36,8 @@ type Supervisor struct {
 	logger     logger.Logger    // logger manager
 	lock       sync.Mutex
 	restarting bool // if supervisor is in restarting state
+	// the autoscalers of the programs with autoscale_max
+	autoscalers []*autoscaler
 }
 
 // StartProcessArgs arguments for starting a process
@@ -59,10 +66,10 @@ type StateInfo struct {
 
 // RPCTaskResult result of some remote commands
 type RPCTaskResult struct {
-	Name        string `xml:"name"`        // the program name
-	Group       string `xml:"group"`       // the group of the program
-	Description string `xml:"description"` // the description of program
-	Status      int    `xml:"status"`      // the status of the program
+	Name        string `xml:"name" json:"name"`               // the program name
+	Group       string `xml:"group" json:"group"`             // the group of the program
+	Description string `xml:"description" json:"description"` // the description of program
+	Status      int    `xml:"status" json:"status"`           // the status of the program
 }
 
 // LogReadInfo the input argument to read the log of supervisor
@@ -79,20 +86,46 @@ type ProcessLogReadInfo struct {
 }
 
 // ProcessTailLog the output of tail the program log
+//
+// Offset is an int because the xml rpc codec only knows how to encode int values
 type ProcessTailLog struct {
 	LogData  string
-	Offset   int64
+	Offset   int
 	Overflow bool
 }
 
+// ConfigInfo the configuration of one program returned by getAllConfigInfo
+type ConfigInfo struct {
+	Name           string `xml:"name" json:"name"`
+	Group          string `xml:"group" json:"group"`
+	Inuse          bool   `xml:"inuse" json:"inuse"`
+	Autostart      bool   `xml:"autostart" json:"autostart"`
+	Command        string `xml:"command" json:"command"`
+	Directory      string `xml:"directory" json:"directory"`
+	ProcessPrio    int    `xml:"process_prio" json:"process_prio"`
+	GroupPrio      int    `xml:"group_prio" json:"group_prio"`
+	Startsecs      int    `xml:"startsecs" json:"startsecs"`
+	Startretries   int    `xml:"startretries" json:"startretries"`
+	Stopsignal     string `xml:"stopsignal" json:"stopsignal"`
+	Stopwaitsecs   int    `xml:"stopwaitsecs" json:"stopwaitsecs"`
+	Stopasgroup    bool   `xml:"stopasgroup" json:"stopasgroup"`
+	Killasgroup    bool   `xml:"killasgroup" json:"killasgroup"`
+	RedirectStderr bool   `xml:"redirect_stderr" json:"redirect_stderr"`
+	StdoutLogfile  string `xml:"stdout_logfile" json:"stdout_logfile"`
+	StderrLogfile  string `xml:"stderr_logfile" json:"stderr_logfile"`
+}
+
 // NewSupervisor create a Supervisor object with supervisor configuration file
 func NewSupervisor(configFile string) *Supervisor {
-	return &Supervisor{
+	s := &Supervisor{
 		config:     config.NewConfig(configFile),
 		procMgr:    process.NewManager(),
 		xmlRPC:     NewXMLRPC(),
 		restarting: false,
 	}
+	s.procMgr.SetShutdownHandler(s.shutdownOnRestartLimit)
+	s.procMgr.SetRollingRestartHandler(s.rollingRestartOnBinaryChange)
+	return s
 }
 
 // GetSupervisorID get the supervisor identifier from configuration file
@@ -104,15 +137,104 @@ func (s *Supervisor) GetSupervisorID() string {
 	return entry.GetString("identifier", "supervisor")
 }
 
+// GetVersion get the version of the supervisor xml rpc API
+func (s *Supervisor) GetVersion(_ *http.Request, _ *struct{}, reply *struct{ Version string }) error {
+	reply.Version = SupervisorVersion
+	return nil
+}
+
+// GetSupervisorVersion get the version of supervisord
+func (s *Supervisor) GetSupervisorVersion(_ *http.Request, _ *struct{}, reply *struct{ Version string }) error {
+	reply.Version = VERSION
+	return nil
+}
+
+// GetIdentification get the supervisor identifier configured in the file
+func (s *Supervisor) GetIdentification(_ *http.Request, _ *struct{}, reply *struct{ ID string }) error {
+	reply.ID = s.GetSupervisorID()
+	return nil
+}
+
+// GetState get the state of supervisor
+func (s *Supervisor) GetState(_ *http.Request, _ *struct{}, reply *struct{ StateInfo StateInfo }) error {
+	// statecode     statename
+	// =======================
+	// 2            FATAL
+	// 1            RUNNING
+	// 0            RESTARTING
+	// -1           SHUTDOWN
+	if s.IsRestarting() {
+		reply.StateInfo.Statecode = 0
+		reply.StateInfo.Statename = "RESTARTING"
+	} else {
+		reply.StateInfo.Statecode = 1
+		reply.StateInfo.Statename = "RUNNING"
+	}
+	return nil
+}
+
+// GetPID get the pid of supervisord
+func (s *Supervisor) GetPID(_ *http.Request, _ *struct{}, reply *struct{ Pid int }) error {
+	reply.Pid = os.Getpid()
+	return nil
+}
+
+// ReadLog read the log of supervisord
+func (s *Supervisor) ReadLog(_ *http.Request, args *LogReadInfo, reply *struct{ Log string }) error {
+	if s.logger == nil {
+		return faults.NewFault(faults.NoFile, "NO_FILE")
+	}
+	data, err := s.logger.ReadLog(int64(args.Offset), int64(args.Length))
+	reply.Log = data
+	return err
+}
+
+// ClearLog clear the log of supervisord
+func (s *Supervisor) ClearLog(_ *http.Request, _ *struct{}, reply *struct{ Ret bool }) error {
+	if s.logger == nil {
+		return faults.NewFault(faults.NoFile, "NO_FILE")
+	}
+	err := s.logger.ClearAllLogFile()
+	reply.Ret = err == nil
+	return err
+}
+
 // Shutdown the supervisor
-func (s *Supervisor) Shutdown(_ *http.Request, _ *struct{}, reply *struct{ Ret bool }) {
+func (s *Supervisor) Shutdown(_ *http.Request, _ *struct{}, reply *struct{ Ret bool }) error {
 	reply.Ret = true
 	log.Info("received rpc request to stop all processes & exit")
-	s.procMgr.StopAllProcesses()
+	s.stopAllProcesses()
 	go func() {
 		time.Sleep(1 * time.Second)
 		os.Exit(0)
 	}()
+	return nil
+}
+
+// stop all the processes and exit with non-zero code because a program exceeds its restart limit,
+// so the container orchestrator can restart supervisord
+func (s *Supervisor) shutdownOnRestartLimit(reason string) {
+	log.WithFields(log.Fields{"reason": reason}).Error("stop all processes & exit")
+	s.stopAllProcesses()
+	os.Exit(1)
+}
+
+// stop all the processes in the reverse depends_on/priority order, the processes still running
+// after "shutdown_timeout" seconds in [supervisord] section are killed
+func (s *Supervisor) stopAllProcesses() {
+	timeout := 0
+	if supervisordConf, ok := s.config.GetSupervisord(); ok {
+		timeout = supervisordConf.GetInt("shutdown_timeout", 0)
+	}
+	s.procMgr.StopAllProcesses(time.Duration(timeout) * time.Second)
+}
+
+// Restart stop all the programs and reload supervisord from the configuration file
+func (s *Supervisor) Restart(_ *http.Request, _ *struct{}, reply *struct{ Ret bool }) error {
+	log.Info("received rpc request to restart")
+	s.restarting = true
+	reply.Ret = true
+	return nil
 }
 
 // IsRestarting check if supervisor is in restarting state
@@ -120,8 +242,15 @@ func (s *Supervisor) IsRestarting() bool {
 	return s.restarting
 }
 
+// returns true if the state is one of the states in which python supervisord
+// considers a process as running, or the process is waiting for its dependencies
+func isRunningState(state process.State) bool {
+	return state == process.Running || state == process.Starting || state == process.Backoff || state == process.Waiting
+}
+
 func getProcessInfo(proc *process.Process) *types.ProcessInfo {
-	return &types.ProcessInfo{
+	backoffDelay, nextAttempt := proc.GetBackoff()
+	info := &types.ProcessInfo{
 		Name:          proc.GetName(),
 		Group:         proc.GetGroup(),
 		Description:   proc.GetDescription(),
@@ -130,23 +259,89 @@ func getProcessInfo(proc *process.Process) *types.ProcessInfo {
 		Now:           int(time.Now().Unix()),
 		State:         int(proc.GetState()),
 		Statename:     proc.GetState().String(),
-		Spawnerr:      "",
+		Spawnerr:      proc.GetSpawnErr(),
 		Exitstatus:    proc.GetExitstatus(),
 		Logfile:       proc.GetStdoutLogfile(),
 		StdoutLogfile: proc.GetStdoutLogfile(),
 		StderrLogfile: proc.GetStderrLogfile(),
 		Pid:           proc.GetPid(),
+		Health:        proc.GetHealth().String(),
+	}
+	if !nextAttempt.IsZero() {
+		info.BackoffDelayMs = int(backoffDelay.Milliseconds())
+		info.NextAttempt = int(nextAttempt.Unix())
+	}
+	return info
+}
+
+func newRPCTaskResult(proc *process.Process, status int, description string) RPCTaskResult {
+	return RPCTaskResult{
+		Name:        proc.GetName(),
+		Group:       proc.GetGroup(),
+		Status:      status,
+		Description: description,
+	}
+}
+
+// GetProcessInfo get the process information of one program
+func (s *Supervisor) GetProcessInfo(_ *http.Request, args *struct{ Name string }, reply *struct{ ProcInfo types.ProcessInfo }) error {
+	proc := s.procMgr.Find(args.Name)
+	if proc == nil {
+		return faults.NewFault(faults.BadName, fmt.Sprintf("no process named %s", args.Name))
 	}
+
+	reply.ProcInfo = *getProcessInfo(proc)
+	return nil
 }
 
 // GetAllProcessInfo get all the program information managed by supervisor
-func (s *Supervisor) GetAllProcessInfo(_ *http.Request, _ *struct{}, reply *struct{ AllProcessInfo []types.ProcessInfo }) {
+func (s *Supervisor) GetAllProcessInfo(_ *http.Request, _ *struct{}, reply *struct{ AllProcessInfo []types.ProcessInfo }) error {
 	reply.AllProcessInfo = make([]types.ProcessInfo, 0)
 	s.procMgr.ForEachProcess(func(proc *process.Process) {
 		procInfo := getProcessInfo(proc)
 		reply.AllProcessInfo = append(reply.AllProcessInfo, *procInfo)
 	})
 	types.SortProcessInfos(reply.AllProcessInfo)
+	return nil
+}
+
+// GetAllConfigInfo get the configuration of all the programs defined in the configuration file
+func (s *Supervisor) GetAllConfigInfo(_ *http.Request, _ *struct{}, reply *struct{ ConfigInfo []ConfigInfo }) error {
+	reply.ConfigInfo = make([]ConfigInfo, 0)
+	for _, entry := range s.config.GetPrograms() {
+		name := entry.GetProgramName()
+		reply.ConfigInfo = append(reply.ConfigInfo, ConfigInfo{
+			Name:           name,
+			Group:          entry.Group,
+			Inuse:          s.procMgr.Find(name) != nil,
+			Autostart:      entry.GetBool("autostart", true),
+			Command:        entry.GetCommand(),
+			Directory:      entry.GetStringExpression("directory", ""),
+			ProcessPrio:    entry.GetInt("priority", 999),
+			GroupPrio:      s.getGroupPriority(entry.Group),
+			Startsecs:      entry.GetInt("startsecs", 1),
+			Startretries:   entry.GetInt("startretries", 3),
+			Stopsignal:     entry.GetString("stopsignal", "SIGTERM"),
+			Stopwaitsecs:   entry.GetInt("stopwaitsecs", 10),
+			Stopasgroup:    entry.GetBool("stopasgroup", false),
+			Killasgroup:    entry.GetBool("killasgroup", entry.GetBool("stopasgroup", false)),
+			RedirectStderr: entry.GetBool("redirect_stderr", false),
+			StdoutLogfile:  entry.GetStringExpression("stdout_logfile", ""),
+			StderrLogfile:  entry.GetStringExpression("stderr_logfile", ""),
+		})
+	}
+	return nil
+}
+
+// get the priority of the group section, or 999 if the group is not defined by a section
+func (s *Supervisor) getGroupPriority(group string) int {
+	groups := s.config.GetEntries(func(entry *config.Entry) bool {
+		return entry.IsGroup() && entry.GetGroupName() == group
+	})
+	if len(groups) == 0 {
+		return 999
+	}
+	return groups[0].GetInt("priority", 999)
 }
 
 // StartProcess start the given program
@@ -154,21 +349,56 @@ func (s *Supervisor) StartProcess(_ *http.Request, args *StartProcessArgs, reply
 	procs := s.procMgr.FindMatch(args.Name)
 
 	if len(procs) == 0 {
-		return fmt.Errorf("fail to find process %s", args.Name)
+		return faults.NewFault(faults.BadName, fmt.Sprintf("fail to find process %s", args.Name))
+	}
+	proc := s.procMgr.Find(args.Name)
+	if proc != nil && isRunningState(proc.GetState()) {
+		return faults.NewFault(faults.AlreadyStated, "ALREADY_STARTED")
 	}
 	for _, proc := range procs {
 		proc.Start(args.Wait)
 	}
+	if proc != nil && args.Wait && proc.GetState() == process.Fatal {
+		return faults.NewFault(faults.SpawnError, "SPAWN_ERROR")
+	}
 	reply.Success = true
 	return nil
 }
 
+// StartAllProcesses start all the programs
+func (s *Supervisor) StartAllProcesses(_ *http.Request, args *struct {
+	Wait bool `default:"true"`
+}, reply *struct{ RPCTaskResults []RPCTaskResult },
+) error {
+	reply.RPCTaskResults = make([]RPCTaskResult, 0)
+	finishedProcCh := make(chan *process.Process)
+
+	n := s.procMgr.AsyncForEachProcess(func(proc *process.Process) {
+		proc.Start(args.Wait)
+	}, finishedProcCh)
+
+	for i := 0; i < n; i++ {
+		proc := <-finishedProcCh
+		reply.RPCTaskResults = append(reply.RPCTaskResults, newRPCTaskResult(proc, faults.Success, "OK"))
+	}
+	return nil
+}
+
+// StartProcessGroup start all the programs in the group in the depends_on/priority order,
+// every program is Running before starting the next one
+func (s *Supervisor) StartProcessGroup(_ *http.Request, args *ProcessGroupArgs, reply *struct{ RPCTaskResults []RPCTaskResult }) error {
+	return s.runGroupOperation(args, "start", reply)
+}
+
 // StopProcess stop given program
 func (s *Supervisor) StopProcess(_ *http.Request, args *StartProcessArgs, reply *struct{ Success bool }) error {
 	log.WithFields(log.Fields{"program": args.Name}).Info("stop process")
 	procs := s.procMgr.FindMatch(args.Name)
 	if len(procs) == 0 {
-		return fmt.Errorf("fail to find process %s", args.Name)
+		return faults.NewFault(faults.BadName, fmt.Sprintf("fail to find process %s", args.Name))
+	}
+	if proc := s.procMgr.Find(args.Name); proc != nil && !isRunningState(proc.GetState()) {
+		return faults.NewFault(faults.NotRunning, "NOT_RUNNING")
 	}
 	for _, proc := range procs {
 		proc.Stop(args.Wait)
@@ -177,8 +407,573 @@ func (s *Supervisor) StopProcess(_ *http.Request, args *StartProcessArgs, reply
 	return nil
 }
 
+// StopProcessGroup stop all the programs in the group in the reverse depends_on/priority order
+func (s *Supervisor) StopProcessGroup(_ *http.Request, args *ProcessGroupArgs, reply *struct{ RPCTaskResults []RPCTaskResult }) error {
+	return s.runGroupOperation(args, "stop", reply)
+}
+
+// RestartProcessGroup stop all the programs in the group in the reverse depends_on/priority
+// order and start them again in the depends_on/priority order
+func (s *Supervisor) RestartProcessGroup(_ *http.Request, args *ProcessGroupArgs, reply *struct{ RPCTaskResults []RPCTaskResult }) error {
+	return s.runGroupOperation(args, "restart", reply)
+}
+
+func (s *Supervisor) runGroupOperation(args *ProcessGroupArgs, action string, reply *struct{ RPCTaskResults []RPCTaskResult }) error {
+	log.WithFields(log.Fields{"group": args.Name}).Infof("%s process group", action)
+	procs := s.procMgr.FindGroup(args.Name)
+	if len(procs) == 0 {
+		return faults.NewFault(faults.BadName, fmt.Sprintf("fail to find group %s", args.Name))
+	}
+	reply.RPCTaskResults = runGroupOperation(procs, action, args.Wait, groupOperationTimeout(args.Timeout))
+	return nil
+}
+
+// StopAllProcesses stop all the programs
+func (s *Supervisor) StopAllProcesses(_ *http.Request, args *struct {
+	Wait bool `default:"true"`
+}, reply *struct{ RPCTaskResults []RPCTaskResult },
+) error {
+	reply.RPCTaskResults = make([]RPCTaskResult, 0)
+	finishedProcCh := make(chan *process.Process)
+
+	n := s.procMgr.AsyncForEachProcess(func(proc *process.Process) {
+		proc.Stop(args.Wait)
+	}, finishedProcCh)
+
+	for i := 0; i < n; i++ {
+		proc := <-finishedProcCh
+		reply.RPCTaskResults = append(reply.RPCTaskResults, newRPCTaskResult(proc, faults.Success, "OK"))
+	}
+	return nil
+}
+
+// SignalProcess send a signal to the program
+func (s *Supervisor) SignalProcess(_ *http.Request, args *types.ProcessSignal, reply *struct{ Success bool }) error {
+	procs := s.procMgr.FindMatch(args.Name)
+	if len(procs) == 0 {
+		return faults.NewFault(faults.BadName, fmt.Sprintf("fail to find process %s", args.Name))
+	}
+	sig, err := signals.ParseSignal(args.Signal)
+	if err != nil {
+		return faults.NewFault(faults.BadSignal, "BAD_SIGNAL")
+	}
+	for _, proc := range procs {
+		if err := proc.Signal(sig, false); err != nil {
+			return faults.NewFault(faults.NotRunning, "NOT_RUNNING")
+		}
+	}
+	reply.Success = true
+	return nil
+}
+
+// SignalProcessGroup send a signal to all the programs in the group
+func (s *Supervisor) SignalProcessGroup(_ *http.Request, args *types.ProcessSignal, reply *struct{ RPCTaskResults []RPCTaskResult }) error {
+	procs := s.procMgr.FindMatch(args.Name + ":*")
+	if len(procs) == 0 {
+		return faults.NewFault(faults.BadName, fmt.Sprintf("fail to find group %s", args.Name))
+	}
+	sig, err := signals.ParseSignal(args.Signal)
+	if err != nil {
+		return faults.NewFault(faults.BadSignal, "BAD_SIGNAL")
+	}
+
+	reply.RPCTaskResults = make([]RPCTaskResult, 0)
+	for _, proc := range procs {
+		reply.RPCTaskResults = append(reply.RPCTaskResults, signalProcess(proc, sig, false))
+	}
+	return nil
+}
+
+// SignalAllProcesses send a signal to all the programs
+func (s *Supervisor) SignalAllProcesses(_ *http.Request, args *types.ProcessSignal, reply *struct{ RPCTaskResults []RPCTaskResult }) error {
+	sig, err := signals.ParseSignal(args.Signal)
+	if err != nil {
+		return faults.NewFault(faults.BadSignal, "BAD_SIGNAL")
+	}
+
+	reply.RPCTaskResults = make([]RPCTaskResult, 0)
+	s.procMgr.ForEachProcess(func(proc *process.Process) {
+		reply.RPCTaskResults = append(reply.RPCTaskResults, signalProcess(proc, sig, false))
+	})
+	return nil
+}
+
+// send the signal to the process, or to its process group if sigChildren is true
+func signalProcess(proc *process.Process, sig os.Signal, sigChildren bool) RPCTaskResult {
+	if err := proc.Signal(sig, sigChildren); err != nil {
+		return newRPCTaskResult(proc, faults.NotRunning, "NOT_RUNNING")
+	}
+	return newRPCTaskResult(proc, faults.Success, "OK")
+}
+
+// start the process if it is not running
+func startProcess(proc *process.Process, wait bool) RPCTaskResult {
+	if isRunningState(proc.GetState()) {
+		return newRPCTaskResult(proc, faults.AlreadyStated, "ALREADY_STARTED")
+	}
+	proc.Start(wait)
+	if state := proc.GetState(); wait && state != process.Running {
+		return newRPCTaskResult(proc, faults.SpawnError, state.String())
+	}
+	return newRPCTaskResult(proc, faults.Success, "OK")
+}
+
+// stop the process if it is running
+func stopProcess(proc *process.Process, wait bool) RPCTaskResult {
+	if !isRunningState(proc.GetState()) {
+		return newRPCTaskResult(proc, faults.NotRunning, "NOT_RUNNING")
+	}
+	proc.Stop(wait)
+	return newRPCTaskResult(proc, faults.Success, "OK")
+}
+
+// stop the process if it is running and start it again
+func restartProcess(proc *process.Process) RPCTaskResult {
+	proc.Restart(true)
+	if state := proc.GetState(); state != process.Running {
+		return newRPCTaskResult(proc, faults.SpawnError, state.String())
+	}
+	return newRPCTaskResult(proc, faults.Success, "OK")
+}
+
+// write the chars to the stdin of the process
+func sendProcessStdin(proc *process.Process, chars string) RPCTaskResult {
+	if proc.GetState() != process.Running {
+		return newRPCTaskResult(proc, faults.NotRunning, "NOT_RUNNING")
+	}
+	if err := proc.SendProcessStdin(chars); err != nil {
+		return newRPCTaskResult(proc, faults.NoFile, "NO_FILE")
+	}
+	return newRPCTaskResult(proc, faults.Success, "OK")
+}
+
+// ScaleProgramArgs arguments for changing the numprocs of a program
+type ScaleProgramArgs struct {
+	Name     string // the program name in [program:x] section
+	Numprocs int    // the new number of the instances
+	Persist  bool   // keep the numprocs across reloads and save it to numprocs_file
+}
+
+// change the numprocs of the program, the added instances are started if autostart is true and
+// the removed instances are stopped
+func (s *Supervisor) scaleProgram(name string, numprocs int, persist bool) (types.ScaleResult, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.doScaleProgram(name, numprocs, persist)
+}
+
+// change the numprocs of the program without persisting it if cond() returns true with the lock,
+// it is used by the autoscalers which are replaced on reload
+func (s *Supervisor) scaleProgramIf(name string, numprocs int, cond func() bool) (types.ScaleResult, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !cond() {
+		return types.ScaleResult{Program: name}, nil
+	}
+	result, err := s.doScaleProgram(name, numprocs, false)
+	if err == nil {
+		s.config.SetAutoscaledNumprocs(name, numprocs)
+	}
+	return result, err
+}
+
+// change the numprocs of the program, it must be called with the lock
+func (s *Supervisor) doScaleProgram(name string, numprocs int, persist bool) (types.ScaleResult, error) {
+	result := types.ScaleResult{Program: name, Numprocs: numprocs}
+	if _, ok := s.config.GetNumprocs(name); !ok {
+		return result, faults.NewFault(faults.BadName, fmt.Sprintf("no program named %s", name))
+	}
+	added, removed, err := s.config.ScaleProgram(name, numprocs, persist)
+	for _, procName := range removed {
+		if proc := s.procMgr.Remove(procName); proc != nil {
+			proc.Stop(false)
+		}
+	}
+	for _, procName := range added {
+		entry := s.config.GetProgram(procName)
+		if entry == nil {
+			continue
+		}
+		proc := s.procMgr.CreateProcess(s.GetSupervisorID(), entry)
+		if entry.GetBool("autostart", true) {
+			proc.Start(false)
+		}
+	}
+	result.Added, result.Removed = added, removed
+	if err != nil {
+		return result, faults.NewFault(faults.BadArguments, err.Error())
+	}
+	log.WithFields(log.Fields{"program": name, "numprocs": numprocs, "added": added, "removed": removed}).Info("scale program")
+	return result, nil
+}
+
+// ScaleProgram changes the number of the instances of the program at runtime
+func (s *Supervisor) ScaleProgram(_ *http.Request, args *ScaleProgramArgs, reply *struct{ Result types.ScaleResult }) error {
+	result, err := s.scaleProgram(args.Name, args.Numprocs, args.Persist)
+	if err != nil {
+		return err
+	}
+	reply.Result = result
+	return nil
+}
+
+// clear the restart history of the process so it is restarted automatically again
+func resetRestartBreaker(proc *process.Process) RPCTaskResult {
+	if !proc.ResetRestartBreaker() {
+		return newRPCTaskResult(proc, faults.Success, "NOT_TRIPPED")
+	}
+	return newRPCTaskResult(proc, faults.Success, "OK")
+}
+
+// ResetRestartBreaker clears the restart history of the processes matched by the name so they are
+// restarted automatically again after exceeding restart_limit. The processes are not started
+func (s *Supervisor) ResetRestartBreaker(_ *http.Request, args *struct{ Name string }, reply *struct{ RPCTaskResults []RPCTaskResult }) error {
+	procs := s.procMgr.FindMatch(args.Name)
+	if len(procs) == 0 {
+		return faults.NewFault(faults.BadName, fmt.Sprintf("fail to find process %s", args.Name))
+	}
+	for _, proc := range procs {
+		reply.RPCTaskResults = append(reply.RPCTaskResults, resetRestartBreaker(proc))
+	}
+	return nil
+}
+
+// SendProcessStdin send the chars to the stdin of the program
+func (s *Supervisor) SendProcessStdin(_ *http.Request, args *ProcessStdin, reply *struct{ Success bool }) error {
+	proc := s.procMgr.Find(args.Name)
+	if proc == nil {
+		return faults.NewFault(faults.BadName, fmt.Sprintf("fail to find process %s", args.Name))
+	}
+	if proc.GetState() != process.Running {
+		return faults.NewFault(faults.NotRunning, "NOT_RUNNING")
+	}
+	if err := proc.SendProcessStdin(args.Chars); err != nil {
+		return faults.NewFault(faults.NoFile, "NO_FILE")
+	}
+	reply.Success = true
+	return nil
+}
+
+// SendRemoteCommEvent emit a REMOTE_COMMUNICATION event to the event listeners
+func (s *Supervisor) SendRemoteCommEvent(_ *http.Request, args *RemoteCommEvent, reply *struct{ Success bool }) error {
+	events.EmitEvent(events.NewRemoteCommunicationEvent(args.Type, args.Data))
+	reply.Success = true
+	return nil
+}
+
+// ReloadConfig reload the configuration file and report the added, changed and removed groups
+//
+// Unlike python supervisord the new configuration is applied immediately, the
+// reply has the same [[added, changed, removed]] shape so supervisorctl can read it.
+// The added, changed and removed programs follow the groups as the second element
+func (s *Supervisor) ReloadConfig(_ *http.Request, _ *struct{}, reply *struct{ Result [][][]string }) error {
+	result, err := s.reload(false)
+	if err != nil {
+		return faults.NewFault(faults.CantReRead, err.Error())
+	}
+	reply.Result = [][][]string{
+		{result.AddedGroup, result.ChangedGroup, result.RemovedGroup},
+		{result.AddedProgram, result.ChangedProgram, result.RemovedProgram},
+	}
+	return nil
+}
+
+// AddProcessGroup create the processes of a group defined in the configuration file
+func (s *Supervisor) AddProcessGroup(_ *http.Request, args *struct{ Name string }, reply *struct{ Success bool }) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entries := s.getGroupPrograms(args.Name)
+	if len(entries) == 0 {
+		return faults.NewFault(faults.BadName, fmt.Sprintf("fail to find group %s", args.Name))
+	}
+	if len(s.procMgr.FindGroup(args.Name)) == 0 {
+		defer events.EmitEvent(events.NewProcessGroupAddedEvent(args.Name))
+	}
+	for _, entry := range entries {
+		if s.procMgr.Find(entry.GetProgramName()) != nil {
+			continue
+		}
+		proc := s.procMgr.CreateProcess(s.GetSupervisorID(), entry)
+		if entry.GetBool("autostart", true) {
+			proc.Start(false)
+		}
+	}
+	reply.Success = true
+	return nil
+}
+
+// RemoveProcessGroup remove the stopped processes of a group from the supervisor
+func (s *Supervisor) RemoveProcessGroup(_ *http.Request, args *struct{ Name string }, reply *struct{ Success bool }) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	procs := s.procMgr.FindMatch(args.Name + ":*")
+	if len(procs) == 0 {
+		return faults.NewFault(faults.BadName, fmt.Sprintf("fail to find group %s", args.Name))
+	}
+	for _, proc := range procs {
+		if isRunningState(proc.GetState()) || proc.GetState() == process.Stopping {
+			return faults.NewFault(faults.StillRunning, "STILL_RUNNING")
+		}
+	}
+	for _, proc := range procs {
+		s.procMgr.Remove(proc.GetName())
+	}
+	events.EmitEvent(events.NewProcessGroupRemovedEvent(args.Name))
+	reply.Success = true
+	return nil
+}
+
+// AddProgramArgs arguments for adding a program at runtime
+type AddProgramArgs struct {
+	Name    string   // the program name
+	Config  []string // the settings of the [program:x] section in key=value format
+	Persist bool     // save the program to programs_dir
+}
+
+// add the program defined by the settings of a [program:x] section and start its processes if
+// autostart is true
+func (s *Supervisor) addProgram(name string, keyValues map[string]string, persist bool) (types.ProgramResult, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	result := types.ProgramResult{Program: name}
+	procNames, err := s.config.AddProgramSection(name, keyValues, persist)
+	if err != nil {
+		return result, faults.NewFault(faults.BadArguments, err.Error())
+	}
+	addedGroups := make([]string, 0)
+	for _, procName := range procNames {
+		entry := s.config.GetProgram(procName)
+		if entry == nil {
+			continue
+		}
+		if len(s.procMgr.FindGroup(entry.Group)) == 0 && !slices.Contains(addedGroups, entry.Group) {
+			addedGroups = append(addedGroups, entry.Group)
+		}
+		proc := s.procMgr.CreateProcess(s.GetSupervisorID(), entry)
+		if entry.GetBool("autostart", true) {
+			proc.Start(false)
+		}
+	}
+	for _, group := range addedGroups {
+		events.EmitEvent(events.NewProcessGroupAddedEvent(group))
+	}
+	log.WithFields(log.Fields{"program": name, "processes": procNames, "persist": persist}).Info("add program")
+	result.Processes = procNames
+	return result, nil
+}
+
+// AddProgram adds a program defined by the settings of a [program:x] section without editing the
+// configuration files, the settings are in key=value format
+func (s *Supervisor) AddProgram(_ *http.Request, args *AddProgramArgs, reply *struct{ Result types.ProgramResult }) error {
+	keyValues := make(map[string]string)
+	for _, setting := range args.Config {
+		key, value, ok := strings.Cut(setting, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return faults.NewFault(faults.BadArguments, fmt.Sprintf("bad setting %q, must be key=value", setting))
+		}
+		keyValues[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	result, err := s.addProgram(args.Name, keyValues, args.Persist)
+	if err != nil {
+		return err
+	}
+	reply.Result = result
+	return nil
+}
+
+// stop the processes of the program and remove the program
+func (s *Supervisor) removeProgram(name string) (types.ProgramResult, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	result := types.ProgramResult{Program: name}
+	if _, ok := s.config.GetNumprocs(name); !ok {
+		return result, faults.NewFault(faults.BadName, fmt.Sprintf("no program named %s", name))
+	}
+	groups := make([]string, 0)
+	for _, procName := range s.config.GetProgramInstanceNames(name) {
+		if proc := s.procMgr.Find(procName); proc != nil {
+			proc.Stop(true)
+			if !slices.Contains(groups, proc.GetGroup()) {
+				groups = append(groups, proc.GetGroup())
+			}
+		}
+	}
+	procNames, err := s.config.RemoveProgramSection(name)
+	if err != nil {
+		return result, faults.NewFault(faults.BadName, err.Error())
+	}
+	for _, procName := range procNames {
+		s.procMgr.Remove(procName)
+	}
+	for _, group := range groups {
+		if len(s.procMgr.FindGroup(group)) == 0 {
+			events.EmitEvent(events.NewProcessGroupRemovedEvent(group))
+		}
+	}
+	log.WithFields(log.Fields{"program": name, "processes": procNames}).Info("remove program")
+	result.Processes = procNames
+	return result, nil
+}
+
+// RemoveProgram stops the processes of the program and removes it, a program defined in the
+// configuration files is added again on reload
+func (s *Supervisor) RemoveProgram(_ *http.Request, args *struct{ Name string }, reply *struct{ Result types.ProgramResult }) error {
+	result, err := s.removeProgram(args.Name)
+	if err != nil {
+		return err
+	}
+	reply.Result = result
+	return nil
+}
+
+// get the configuration of all the programs in the group
+func (s *Supervisor) getGroupPrograms(group string) []*config.Entry {
+	result := make([]*config.Entry, 0)
+	for _, entry := range s.config.GetPrograms() {
+		if entry.Group == group {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// get the stdout or stderr logger of the program
+func (s *Supervisor) getProcessLogger(name string, stderr bool) (logger.Logger, error) {
+	proc := s.procMgr.Find(name)
+	if proc == nil {
+		return nil, faults.NewFault(faults.BadName, fmt.Sprintf("fail to find process %s", name))
+	}
+	procLogger := proc.StdoutLog
+	if stderr {
+		procLogger = proc.StderrLog
+	}
+	if procLogger == nil {
+		return nil, faults.NewFault(faults.NoFile, "NO_FILE")
+	}
+	return procLogger, nil
+}
+
+// read at most length bytes of the process log from offset, the last length bytes
+// are read if the offset is negative. The log, the offset for next reading and the
+// overflow flag are returned
+func (s *Supervisor) tailLog(name string, stderr bool, offset int, length int) (string, int64, bool, error) {
+	procLogger, err := s.getProcessLogger(name, stderr)
+	if err != nil {
+		return "", 0, false, err
+	}
+	if offset < 0 {
+		// read from the end of log to get the log length
+		_, logLength, _, err := procLogger.ReadTailLog(math.MaxInt64, 0)
+		if err != nil {
+			return "", 0, false, err
+		}
+		offset = int(logLength) - length
+		if offset < 0 {
+			offset = 0
+		}
+	}
+	return procLogger.ReadTailLog(int64(offset), int64(length))
+}
+
+// ReadProcessStdoutLog read the stdout log of the program
+func (s *Supervisor) ReadProcessStdoutLog(_ *http.Request, args *ProcessLogReadInfo, reply *struct{ LogData string }) error {
+	procLogger, err := s.getProcessLogger(args.Name, false)
+	if err != nil {
+		return err
+	}
+	reply.LogData, err = procLogger.ReadLog(int64(args.Offset), int64(args.Length))
+	return err
+}
+
+// ReadProcessStderrLog read the stderr log of the program
+func (s *Supervisor) ReadProcessStderrLog(_ *http.Request, args *ProcessLogReadInfo, reply *struct{ LogData string }) error {
+	procLogger, err := s.getProcessLogger(args.Name, true)
+	if err != nil {
+		return err
+	}
+	reply.LogData, err = procLogger.ReadLog(int64(args.Offset), int64(args.Length))
+	return err
+}
+
+// TailProcessStdoutLog tail the stdout log of the program
+func (s *Supervisor) TailProcessStdoutLog(_ *http.Request, args *ProcessLogReadInfo, reply *ProcessTailLog) error {
+	procLogger, err := s.getProcessLogger(args.Name, false)
+	if err != nil {
+		return err
+	}
+	return tailProcessLog(procLogger, args, reply)
+}
+
+// TailProcessStderrLog tail the stderr log of the program
+func (s *Supervisor) TailProcessStderrLog(_ *http.Request, args *ProcessLogReadInfo, reply *ProcessTailLog) error {
+	procLogger, err := s.getProcessLogger(args.Name, true)
+	if err != nil {
+		return err
+	}
+	return tailProcessLog(procLogger, args, reply)
+}
+
+func tailProcessLog(procLogger logger.Logger, args *ProcessLogReadInfo, reply *ProcessTailLog) error {
+	logData, offset, overflow, err := procLogger.ReadTailLog(int64(args.Offset), int64(args.Length))
+	if err != nil {
+		return err
+	}
+	reply.LogData = logData
+	reply.Offset = int(offset)
+	reply.Overflow = overflow
+	return nil
+}
+
+// ClearProcessLogs clear the stdout and stderr logs of the program
+func (s *Supervisor) ClearProcessLogs(_ *http.Request, args *struct{ Name string }, reply *struct{ Success bool }) error {
+	proc := s.procMgr.Find(args.Name)
+	if proc == nil {
+		return faults.NewFault(faults.BadName, fmt.Sprintf("fail to find process %s", args.Name))
+	}
+	if err := clearProcessLogs(proc); err != nil {
+		return err
+	}
+	reply.Success = true
+	return nil
+}
+
+// ClearAllProcessLogs clear the stdout and stderr logs of all the programs
+func (s *Supervisor) ClearAllProcessLogs(_ *http.Request, _ *struct{}, reply *struct{ RPCTaskResults []RPCTaskResult }) error {
+	reply.RPCTaskResults = make([]RPCTaskResult, 0)
+	s.procMgr.ForEachProcess(func(proc *process.Process) {
+		if err := clearProcessLogs(proc); err != nil {
+			reply.RPCTaskResults = append(reply.RPCTaskResults, newRPCTaskResult(proc, faults.Failed, err.Error()))
+		} else {
+			reply.RPCTaskResults = append(reply.RPCTaskResults, newRPCTaskResult(proc, faults.Success, "OK"))
+		}
+	})
+	return nil
+}
+
+func clearProcessLogs(proc *process.Process) error {
+	if proc.StdoutLog == nil || proc.StderrLog == nil {
+		return faults.NewFault(faults.NoFile, "NO_FILE")
+	}
+	err1 := proc.StdoutLog.ClearAllLogFile()
+	err2 := proc.StderrLog.ClearAllLogFile()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
 // Reload supervisord configuration.
 func (s *Supervisor) Reload(restart bool) error {
+	_, err := s.reload(restart)
+	return err
+}
+
+// reload the configuration and return the added, changed and removed groups
+func (s *Supervisor) reload(restart bool) (types.ReloadConfigResult, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
@@ -186,21 +981,30 @@ func (s *Supervisor) Reload(restart bool) error {
 	prevPrograms := s.config.GetProgramNames()
 	prevProgGroup := s.config.ProgramGroup.Clone()
 
-	loadedPrograms, err := s.config.Load()
+	var result types.ReloadConfigResult
+	loadedPrograms, changedPrograms, err := s.config.Load()
+	if err != nil {
+		log.WithFields(log.Fields{log.ErrorKey: err}).Error("fail to load the configuration, keep the current configuration")
+		return result, err
+	}
+
+	for _, d := range checkProgramCommands(s.config, config.SeverityWarning) {
+		log.WithFields(log.Fields{"file": d.File, "line": d.Line, "section": d.Section}).Warn(d.Message)
+	}
 
 	if checkErr := s.checkRequiredResources(); checkErr != nil {
 		panic(checkErr)
 	}
 
-	if err == nil {
-		s.setSupervisordInfo()
-		s.startEventListeners()
-		s.createPrograms(prevPrograms)
-		if restart {
-			s.startHTTPServer()
-		}
-		s.startAutoStartPrograms()
+	s.setSupervisordInfo()
+	s.startEventListeners()
+	s.createPrograms()
+	if restart {
+		s.startHTTPServer()
 	}
+	s.restartChangedPrograms(changedPrograms)
+	s.startAutoStartPrograms()
+	s.startAutoscalers()
 	removedPrograms := util.Sub(prevPrograms, loadedPrograms)
 	for _, removedProg := range removedPrograms {
 		log.WithFields(log.Fields{"program": removedProg}).Info("the program is removed and will be stopped")
@@ -211,31 +1015,57 @@ func (s *Supervisor) Reload(restart bool) error {
 		}
 	}
 
-	s.config.ProgramGroup.Sub(prevProgGroup)
+	result.AddedGroup, result.ChangedGroup, result.RemovedGroup = s.config.ProgramGroup.Sub(prevProgGroup)
+	// a group is also changed if the settings of its programs are changed
+	for _, name := range changedPrograms {
+		group := s.config.ProgramGroup.GetGroup(name, name)
+		if !slices.Contains(result.AddedGroup, group) && !slices.Contains(result.ChangedGroup, group) {
+			result.ChangedGroup = append(result.ChangedGroup, group)
+		}
+	}
+	for _, group := range result.AddedGroup {
+		events.EmitEvent(events.NewProcessGroupAddedEvent(group))
+	}
+	for _, group := range result.RemovedGroup {
+		events.EmitEvent(events.NewProcessGroupRemovedEvent(group))
+	}
+	result.AddedProgram = util.Sub(loadedPrograms, prevPrograms)
+	result.ChangedProgram = changedPrograms
+	result.RemovedProgram = removedPrograms
 
-	return err
+	return result, nil
+}
+
+// restart the running programs whose settings are changed by reload, the other programs keep
+// running with the settings updated in place
+func (s *Supervisor) restartChangedPrograms(changedPrograms []string) {
+	for _, name := range changedPrograms {
+		proc := s.procMgr.Find(name)
+		if proc == nil || !isRunningState(proc.GetState()) {
+			continue
+		}
+		log.WithFields(log.Fields{"program": name}).Info("the settings of the program are changed, restart it")
+		go proc.Restart(false)
+	}
 }
 
 // WaitForExit waits for supervisord to exit
 func (s *Supervisor) WaitForExit() {
 	for {
 		if s.IsRestarting() {
-			s.procMgr.StopAllProcesses()
+			s.stopAllProcesses()
+			s.xmlRPC.Stop()
 			break
 		}
 		time.Sleep(10 * time.Second)
 	}
 }
 
-func (s *Supervisor) createPrograms(prevPrograms []string) {
-	programs := s.config.GetProgramNames()
+// create the processes of the programs, the removed programs are stopped and removed by reload
+func (s *Supervisor) createPrograms() {
 	for _, entry := range s.config.GetPrograms() {
 		s.procMgr.CreateProcess(s.GetSupervisorID(), entry)
 	}
-	removedPrograms := util.Sub(prevPrograms, programs)
-	for _, p := range removedPrograms {
-		s.procMgr.Remove(p)
-	}
 }
 
 func (s *Supervisor) startAutoStartPrograms() {
@@ -20,6 +20,10 @@ type ProcessInfo struct {
 	StdoutLogfile string `xml:"stdout_logfile" json:"stdout_logfile"`
 	StderrLogfile string `xml:"stderr_logfile" json:"stderr_logfile"`
 	Pid           int    `xml:"pid" json:"pid"`
+	Health        string `xml:"health" json:"health"`
+	// the delay in milliseconds before the next start attempt and the unix time of the attempt, 0 if no attempt is scheduled
+	BackoffDelayMs int `xml:"backoff_delay_ms" json:"backoff_delay_ms"`
+	NextAttempt    int `xml:"next_attempt" json:"next_attempt"`
 }
 
 // ReloadConfigResult the result of supervisor configuration reloading
@@ -27,6 +31,32 @@ type ReloadConfigResult struct {
 	AddedGroup   []string
 	ChangedGroup []string
 	RemovedGroup []string
+	// the programs are changed if their settings are changed, they are restarted if running
+	AddedProgram   []string
+	ChangedProgram []string
+	RemovedProgram []string
+}
+
+// ScaleResult the result of changing the numprocs of a program
+type ScaleResult struct {
+	Program  string   `xml:"program" json:"program"`
+	Numprocs int      `xml:"numprocs" json:"numprocs"`
+	Added    []string `xml:"added" json:"added"`
+	Removed  []string `xml:"removed" json:"removed"`
+}
+
+// ProgramResult the processes of a program added or removed at runtime
+type ProgramResult struct {
+	Program   string   `xml:"program" json:"program"`
+	Processes []string `xml:"processes" json:"processes"`
+}
+
+// TaskResult the result of the operation on one process
+type TaskResult struct {
+	Name        string `xml:"name" json:"name"`
+	Group       string `xml:"group" json:"group"`
+	Description string `xml:"description" json:"description"`
+	Status      int    `xml:"status" json:"status"`
 }
 
 // ProcessSignal process signal includes program name and signal sent to it
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/gorilla/rpc"
+	"github.com/ochinchina/gorilla-xmlrpc/xml"
 	"github.com/ochinchina/supervisord/process"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -108,6 +110,61 @@ func getProgramConfigPath(programName string, s *Supervisor) string {
 	return c.GetString("conf_file", "")
 }
 
+// create the xml rpc server which maps the python supervisord "supervisor.*" methods to the Supervisor methods
+func (p *XMLRPC) createRPCServer(s *Supervisor) *rpc.Server {
+	RPC := rpc.NewServer()
+	xmlrpcCodec := xml.NewCodec()
+	RPC.RegisterCodec(xmlrpcCodec, "text/xml")
+	if err := RPC.RegisterService(s, ""); err != nil {
+		log.WithFields(log.Fields{log.ErrorKey: err}).Error("fail to register the xml rpc service")
+	}
+
+	xmlrpcCodec.RegisterAlias("supervisor.getVersion", "Supervisor.GetVersion")
+	xmlrpcCodec.RegisterAlias("supervisor.getAPIVersion", "Supervisor.GetVersion")
+	xmlrpcCodec.RegisterAlias("supervisor.getSupervisorVersion", "Supervisor.GetSupervisorVersion")
+	xmlrpcCodec.RegisterAlias("supervisor.getIdentification", "Supervisor.GetIdentification")
+	xmlrpcCodec.RegisterAlias("supervisor.getState", "Supervisor.GetState")
+	xmlrpcCodec.RegisterAlias("supervisor.getPID", "Supervisor.GetPID")
+	xmlrpcCodec.RegisterAlias("supervisor.readLog", "Supervisor.ReadLog")
+	xmlrpcCodec.RegisterAlias("supervisor.readMainLog", "Supervisor.ReadLog")
+	xmlrpcCodec.RegisterAlias("supervisor.clearLog", "Supervisor.ClearLog")
+	xmlrpcCodec.RegisterAlias("supervisor.shutdown", "Supervisor.Shutdown")
+	xmlrpcCodec.RegisterAlias("supervisor.restart", "Supervisor.Restart")
+	xmlrpcCodec.RegisterAlias("supervisor.getProcessInfo", "Supervisor.GetProcessInfo")
+	xmlrpcCodec.RegisterAlias("supervisor.getAllProcessInfo", "Supervisor.GetAllProcessInfo")
+	xmlrpcCodec.RegisterAlias("supervisor.getAllConfigInfo", "Supervisor.GetAllConfigInfo")
+	xmlrpcCodec.RegisterAlias("supervisor.startProcess", "Supervisor.StartProcess")
+	xmlrpcCodec.RegisterAlias("supervisor.startAllProcesses", "Supervisor.StartAllProcesses")
+	xmlrpcCodec.RegisterAlias("supervisor.startProcessGroup", "Supervisor.StartProcessGroup")
+	xmlrpcCodec.RegisterAlias("supervisor.stopProcess", "Supervisor.StopProcess")
+	xmlrpcCodec.RegisterAlias("supervisor.stopProcessGroup", "Supervisor.StopProcessGroup")
+	xmlrpcCodec.RegisterAlias("supervisor.stopAllProcesses", "Supervisor.StopAllProcesses")
+	xmlrpcCodec.RegisterAlias("supervisor.restartProcessGroup", "Supervisor.RestartProcessGroup")
+	xmlrpcCodec.RegisterAlias("supervisor.rollingRestartProgram", "Supervisor.RollingRestartProgram")
+	xmlrpcCodec.RegisterAlias("supervisor.addProgram", "Supervisor.AddProgram")
+	xmlrpcCodec.RegisterAlias("supervisor.removeProgram", "Supervisor.RemoveProgram")
+	xmlrpcCodec.RegisterAlias("supervisor.signalProcess", "Supervisor.SignalProcess")
+	xmlrpcCodec.RegisterAlias("supervisor.resetRestartBreaker", "Supervisor.ResetRestartBreaker")
+	xmlrpcCodec.RegisterAlias("supervisor.scaleProgram", "Supervisor.ScaleProgram")
+	xmlrpcCodec.RegisterAlias("supervisor.signalProcessGroup", "Supervisor.SignalProcessGroup")
+	xmlrpcCodec.RegisterAlias("supervisor.signalAllProcesses", "Supervisor.SignalAllProcesses")
+	xmlrpcCodec.RegisterAlias("supervisor.sendProcessStdin", "Supervisor.SendProcessStdin")
+	xmlrpcCodec.RegisterAlias("supervisor.sendRemoteCommEvent", "Supervisor.SendRemoteCommEvent")
+	xmlrpcCodec.RegisterAlias("supervisor.reloadConfig", "Supervisor.ReloadConfig")
+	xmlrpcCodec.RegisterAlias("supervisor.addProcessGroup", "Supervisor.AddProcessGroup")
+	xmlrpcCodec.RegisterAlias("supervisor.removeProcessGroup", "Supervisor.RemoveProcessGroup")
+	xmlrpcCodec.RegisterAlias("supervisor.readProcessStdoutLog", "Supervisor.ReadProcessStdoutLog")
+	xmlrpcCodec.RegisterAlias("supervisor.readProcessLog", "Supervisor.ReadProcessStdoutLog")
+	xmlrpcCodec.RegisterAlias("supervisor.readProcessStderrLog", "Supervisor.ReadProcessStderrLog")
+	xmlrpcCodec.RegisterAlias("supervisor.tailProcessStdoutLog", "Supervisor.TailProcessStdoutLog")
+	xmlrpcCodec.RegisterAlias("supervisor.tailProcessLog", "Supervisor.TailProcessStdoutLog")
+	xmlrpcCodec.RegisterAlias("supervisor.tailProcessStderrLog", "Supervisor.TailProcessStderrLog")
+	xmlrpcCodec.RegisterAlias("supervisor.clearProcessLogs", "Supervisor.ClearProcessLogs")
+	xmlrpcCodec.RegisterAlias("supervisor.clearProcessLog", "Supervisor.ClearProcessLogs")
+	xmlrpcCodec.RegisterAlias("supervisor.clearAllProcessLogs", "Supervisor.ClearAllProcessLogs")
+	return RPC
+}
+
 func (p *XMLRPC) startHTTPServer(user, password, protocol, listenAddr string, s *Supervisor, startedCb func()) {
 	if p.isHTTPServerStartedOnProtocol(protocol) {
 		startedCb()
@@ -117,6 +174,7 @@ func (p *XMLRPC) startHTTPServer(user, password, protocol, listenAddr string, s
 	_ = prometheus.Register(procCollector)
 
 	mux := http.NewServeMux()
+	mux.Handle("/RPC2", newHTTPBasicAuth(user, password, p.createRPCServer(s)))
 
 	progRestHandler := NewSupervisorRestful(s).CreateProgramHandler()
 	mux.Handle("/program/", newHTTPBasicAuth(user, password, progRestHandler))
@@ -124,6 +182,12 @@ func (p *XMLRPC) startHTTPServer(user, password, protocol, listenAddr string, s
 	supervisorRestHandler := NewSupervisorRestful(s).CreateSupervisorHandler()
 	mux.Handle("/supervisor/", newHTTPBasicAuth(user, password, supervisorRestHandler))
 
+	apiV2Handler := NewAPIv2(s).CreateHandler()
+	mux.Handle("/api/v2/", newHTTPBasicAuth(user, password, apiV2Handler))
+
+	eventHandler := NewSupervisorRestful(s).CreateEventHandler()
+	mux.Handle("/events", newHTTPBasicAuth(user, password, eventHandler))
+
 	// Config file
 	confHandler := NewConfAPI(s).CreateHandler()
 
@@ -0,0 +1,234 @@
+package xmlrpcclient
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Fault the xml rpc fault returned by the server
+type Fault struct {
+	Code   int
+	String string
+}
+
+// Error implements the error interface
+func (f *Fault) Error() string {
+	return fmt.Sprintf("%d: %s", f.Code, f.String)
+}
+
+// encode the method call with the given parameters. Only string, int, bool and
+// string array parameters are needed by the supervisor API
+func encodeMethodCall(method string, params ...interface{}) ([]byte, error) {
+	buf := bytes.NewBufferString(`<?xml version="1.0"?><methodCall><methodName>`)
+	if err := xml.EscapeText(buf, []byte(method)); err != nil {
+		return nil, err
+	}
+	buf.WriteString("</methodName><params>")
+	for _, param := range params {
+		buf.WriteString("<param><value>")
+		switch v := param.(type) {
+		case string:
+			buf.WriteString("<string>")
+			if err := xml.EscapeText(buf, []byte(v)); err != nil {
+				return nil, err
+			}
+			buf.WriteString("</string>")
+		case int:
+			fmt.Fprintf(buf, "<int>%d</int>", v)
+		case bool:
+			if v {
+				buf.WriteString("<boolean>1</boolean>")
+			} else {
+				buf.WriteString("<boolean>0</boolean>")
+			}
+		case []string:
+			buf.WriteString("<array><data>")
+			for _, item := range v {
+				buf.WriteString("<value><string>")
+				if err := xml.EscapeText(buf, []byte(item)); err != nil {
+					return nil, err
+				}
+				buf.WriteString("</string></value>")
+			}
+			buf.WriteString("</data></array>")
+		default:
+			return nil, fmt.Errorf("unsupported parameter type %T", param)
+		}
+		buf.WriteString("</value></param>")
+	}
+	buf.WriteString("</params></methodCall>")
+	return buf.Bytes(), nil
+}
+
+// decode the method response into generic values: string, int, bool, float64,
+// []interface{} for arrays and map[string]interface{} for structs.
+//
+// A response with one parameter returns the parameter itself, a response with
+// several parameters returns them as []interface{}
+func decodeMethodResponse(r io.Reader) (interface{}, error) {
+	decoder := xml.NewDecoder(r)
+	params := make([]interface{}, 0)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "fault":
+			return nil, decodeFault(decoder)
+		case "value":
+			v, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, v)
+		}
+	}
+	switch len(params) {
+	case 0:
+		return nil, fmt.Errorf("no value in the xml rpc response")
+	case 1:
+		return params[0], nil
+	default:
+		return params, nil
+	}
+}
+
+func decodeFault(decoder *xml.Decoder) error {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "value" {
+			v, err := decodeValue(decoder)
+			if err != nil {
+				return err
+			}
+			m, _ := v.(map[string]interface{})
+			code, _ := m["faultCode"].(int)
+			desc, _ := m["faultString"].(string)
+			return &Fault{Code: code, String: desc}
+		}
+	}
+}
+
+// decode the content of a <value> element, the <value> start element is already consumed
+func decodeValue(decoder *xml.Decoder) (interface{}, error) {
+	var text strings.Builder
+	var result interface{}
+	typed := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if !typed {
+				// a value without type is a string
+				return text.String(), nil
+			}
+			return result, nil
+		case xml.StartElement:
+			typed = true
+			result, err = decodeTypedValue(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func decodeTypedValue(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "array":
+		return decodeArray(decoder)
+	case "struct":
+		return decodeStruct(decoder)
+	case "nil":
+		return nil, decoder.Skip()
+	}
+
+	var s string
+	if err := decoder.DecodeElement(&s, &start); err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "int", "i4", "i8":
+		return strconv.Atoi(strings.TrimSpace(s))
+	case "boolean":
+		return strings.TrimSpace(s) == "1", nil
+	case "double":
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	default:
+		return s, nil
+	}
+}
+
+func decodeArray(decoder *xml.Decoder) (interface{}, error) {
+	result := make([]interface{}, 0)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "value" {
+				v, err := decodeValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, v)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "array" {
+				return result, nil
+			}
+		}
+	}
+}
+
+func decodeStruct(decoder *xml.Decoder) (interface{}, error) {
+	result := make(map[string]interface{})
+	name := ""
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "name":
+				if err := decoder.DecodeElement(&name, &t); err != nil {
+					return nil, err
+				}
+			case "value":
+				v, err := decodeValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				result[name] = v
+			}
+		case xml.EndElement:
+			if t.Name.Local == "struct" {
+				return result, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,5 @@
+module github.com/ochinchina/supervisord/xmlrpcclient
+
+go 1.23.0
+
+require github.com/ochinchina/supervisord/types v0.0.0-20230902082938-c2cae38b7454
@@ -0,0 +1,317 @@
+package xmlrpcclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ochinchina/supervisord/types"
+)
+
+// XMLRPCClient the client to call the supervisor xml rpc interface of a running supervisord
+type XMLRPCClient struct {
+	serverurl string
+	user      string
+	password  string
+	timeout   time.Duration
+}
+
+// NewXMLRPCClient creates XMLRPCClient object. The serverurl is in one of following format:
+//
+//	unix:///tmp/supervisord.sock
+//	http://127.0.0.1:9001
+//	127.0.0.1:9001
+func NewXMLRPCClient(serverurl string) *XMLRPCClient {
+	return &XMLRPCClient{serverurl: serverurl, timeout: 30 * time.Second}
+}
+
+// SetUser sets the user name for http basic authentication
+func (r *XMLRPCClient) SetUser(user string) {
+	r.user = user
+}
+
+// SetPassword sets the password for http basic authentication
+func (r *XMLRPCClient) SetPassword(password string) {
+	r.password = password
+}
+
+// SetTimeout sets the timeout of one xml rpc call, 0 means no timeout
+func (r *XMLRPCClient) SetTimeout(timeout time.Duration) {
+	r.timeout = timeout
+}
+
+// create the http client and the url of the xml rpc endpoint
+func (r *XMLRPCClient) createHTTPClient() (*http.Client, string) {
+	client := &http.Client{Timeout: r.timeout}
+	if strings.HasPrefix(r.serverurl, "unix://") {
+		sockFile := r.serverurl[len("unix://"):]
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", sockFile)
+			},
+		}
+		return client, "http://unix/RPC2"
+	}
+	url := r.serverurl
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		url = "http://" + url
+	}
+	return client, strings.TrimSuffix(url, "/") + "/RPC2"
+}
+
+// Call calls the xml rpc method with the parameters and returns the decoded result
+func (r *XMLRPCClient) Call(method string, params ...interface{}) (interface{}, error) {
+	body, err := encodeMethodCall(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	client, url := r.createHTTPClient()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/xml")
+	if r.user != "" && r.password != "" {
+		req.SetBasicAuth(r.user, r.password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("authorization failed, check the user name and password")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad response from %s: %s", r.serverurl, resp.Status)
+	}
+	return decodeMethodResponse(resp.Body)
+}
+
+// convert the generic value decoded from xml rpc response to the typed result
+func convert(value interface{}, result interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, result)
+}
+
+// GetVersion gets the version of supervisord
+func (r *XMLRPCClient) GetVersion() (string, error) {
+	v, err := r.Call("supervisor.getSupervisorVersion")
+	if err != nil {
+		return "", err
+	}
+	version, _ := v.(string)
+	return version, nil
+}
+
+// GetPID gets the pid of supervisord
+func (r *XMLRPCClient) GetPID() (int, error) {
+	v, err := r.Call("supervisor.getPID")
+	if err != nil {
+		return 0, err
+	}
+	pid, _ := v.(int)
+	return pid, nil
+}
+
+// GetAllProcessInfo gets the information of all the processes
+func (r *XMLRPCClient) GetAllProcessInfo() ([]types.ProcessInfo, error) {
+	v, err := r.Call("supervisor.getAllProcessInfo")
+	if err != nil {
+		return nil, err
+	}
+	result := make([]types.ProcessInfo, 0)
+	err = convert(v, &result)
+	return result, err
+}
+
+// GetProcessInfo gets the information of one process
+func (r *XMLRPCClient) GetProcessInfo(name string) (types.ProcessInfo, error) {
+	var result types.ProcessInfo
+	v, err := r.Call("supervisor.getProcessInfo", name)
+	if err != nil {
+		return result, err
+	}
+	err = convert(v, &result)
+	return result, err
+}
+
+// StartProcess starts the process and waits it is started if wait is true
+func (r *XMLRPCClient) StartProcess(name string, wait bool) error {
+	_, err := r.Call("supervisor.startProcess", name, wait)
+	return err
+}
+
+// StopProcess stops the process and waits it is stopped if wait is true
+func (r *XMLRPCClient) StopProcess(name string, wait bool) error {
+	_, err := r.Call("supervisor.stopProcess", name, wait)
+	return err
+}
+
+// StartProcessGroup starts the processes of the group one by one in the dependency order.
+// The timeout is the seconds to wait for the whole group, 0 for the server default
+func (r *XMLRPCClient) StartProcessGroup(name string, timeout int) ([]types.TaskResult, error) {
+	return r.callGroupOperation("supervisor.startProcessGroup", name, timeout)
+}
+
+// StopProcessGroup stops the processes of the group one by one in the reverse dependency order
+func (r *XMLRPCClient) StopProcessGroup(name string, timeout int) ([]types.TaskResult, error) {
+	return r.callGroupOperation("supervisor.stopProcessGroup", name, timeout)
+}
+
+// RestartProcessGroup stops the processes of the group in the reverse dependency order and
+// starts them again in the dependency order
+func (r *XMLRPCClient) RestartProcessGroup(name string, timeout int) ([]types.TaskResult, error) {
+	return r.callGroupOperation("supervisor.restartProcessGroup", name, timeout)
+}
+
+// RollingRestartProgram restarts the instances of the program batch by batch, every batch must be
+// Running and healthy in timeout seconds before restarting the next one
+func (r *XMLRPCClient) RollingRestartProgram(name string, batch int, timeout int) ([]types.TaskResult, error) {
+	v, err := r.Call("supervisor.rollingRestartProgram", name, batch, timeout)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]types.TaskResult, 0)
+	err = convert(v, &result)
+	return result, err
+}
+
+func (r *XMLRPCClient) callGroupOperation(method string, name string, timeout int) ([]types.TaskResult, error) {
+	v, err := r.Call(method, name, true, timeout)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]types.TaskResult, 0)
+	err = convert(v, &result)
+	return result, err
+}
+
+// SignalProcess sends the signal to the process
+func (r *XMLRPCClient) SignalProcess(name string, signal string) error {
+	_, err := r.Call("supervisor.signalProcess", name, signal)
+	return err
+}
+
+// ResetRestartBreaker clears the restart history of the processes so they are restarted
+// automatically again after exceeding their restart limit
+func (r *XMLRPCClient) ResetRestartBreaker(name string) ([]types.TaskResult, error) {
+	v, err := r.Call("supervisor.resetRestartBreaker", name)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]types.TaskResult, 0)
+	err = convert(v, &result)
+	return result, err
+}
+
+// ScaleProgram changes the number of the instances of the program, the numprocs is kept across
+// reloads if persist is true
+func (r *XMLRPCClient) ScaleProgram(name string, numprocs int, persist bool) (types.ScaleResult, error) {
+	var result types.ScaleResult
+	v, err := r.Call("supervisor.scaleProgram", name, numprocs, persist)
+	if err != nil {
+		return result, err
+	}
+	err = convert(v, &result)
+	return result, err
+}
+
+// AddProgram adds a program defined by the settings of a [program:x] section in key=value
+// format, the program is saved to programs_dir if persist is true
+func (r *XMLRPCClient) AddProgram(name string, settings []string, persist bool) (types.ProgramResult, error) {
+	var result types.ProgramResult
+	v, err := r.Call("supervisor.addProgram", name, settings, persist)
+	if err != nil {
+		return result, err
+	}
+	err = convert(v, &result)
+	return result, err
+}
+
+// RemoveProgram stops the processes of the program and removes it
+func (r *XMLRPCClient) RemoveProgram(name string) (types.ProgramResult, error) {
+	var result types.ProgramResult
+	v, err := r.Call("supervisor.removeProgram", name)
+	if err != nil {
+		return result, err
+	}
+	err = convert(v, &result)
+	return result, err
+}
+
+// ReadProcessLog reads the stdout or stderr log of the process
+func (r *XMLRPCClient) ReadProcessLog(name string, stream string, offset int, length int) (string, error) {
+	v, err := r.Call(logMethod("supervisor.read", stream), name, offset, length)
+	if err != nil {
+		return "", err
+	}
+	data, _ := v.(string)
+	return data, nil
+}
+
+// TailProcessLog reads the stdout or stderr log of the process from offset and
+// returns the log, the offset to read next time and the overflow flag
+func (r *XMLRPCClient) TailProcessLog(name string, stream string, offset int, length int) (string, int, bool, error) {
+	v, err := r.Call(logMethod("supervisor.tail", stream), name, offset, length)
+	if err != nil {
+		return "", offset, false, err
+	}
+	values, ok := v.([]interface{})
+	if !ok || len(values) != 3 {
+		return "", offset, false, fmt.Errorf("unexpected tail log response")
+	}
+	data, _ := values[0].(string)
+	nextOffset, _ := values[1].(int)
+	overflow, _ := values[2].(bool)
+	return data, nextOffset, overflow, nil
+}
+
+func logMethod(prefix string, stream string) string {
+	if stream == "stderr" {
+		return prefix + "ProcessStderrLog"
+	}
+	return prefix + "ProcessStdoutLog"
+}
+
+// ReloadConfig reloads the configuration file and returns the added, changed and removed groups
+// and programs
+func (r *XMLRPCClient) ReloadConfig() (types.ReloadConfigResult, error) {
+	var result types.ReloadConfigResult
+	v, err := r.Call("supervisor.reloadConfig")
+	if err != nil {
+		return result, err
+	}
+	groups := make([][][]string, 0)
+	if err = convert(v, &groups); err != nil {
+		return result, err
+	}
+	if len(groups) >= 1 && len(groups[0]) == 3 {
+		result.AddedGroup = groups[0][0]
+		result.ChangedGroup = groups[0][1]
+		result.RemovedGroup = groups[0][2]
+	}
+	// the programs are not reported by the older servers
+	if len(groups) >= 2 && len(groups[1]) == 3 {
+		result.AddedProgram = groups[1][0]
+		result.ChangedProgram = groups[1][1]
+		result.RemovedProgram = groups[1][2]
+	}
+	return result, nil
+}
+
+// Shutdown stops all the processes and shuts down supervisord
+func (r *XMLRPCClient) Shutdown() error {
+	_, err := r.Call("supervisor.shutdown")
+	return err
+}